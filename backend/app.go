@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 )
 
 //go:embed json/*
@@ -14,11 +16,25 @@ var defaultConfigFS embed.FS
 // App struct - flattened design with direct method implementation
 type App struct {
 	ctx            context.Context
+	dataRoot       string
 	configPath     string
 	historyPath    string
 	templatesPath  string
 	banksPath      string
 	categoriesPath string
+	usagePath      string
+	attemptsPath   string
+	rootMu         sync.RWMutex // Held for writing while SetDataRoot moves the data root
+	imageMu        sync.Mutex   // Guards the image store index
+	historyMu      sync.Mutex   // Guards the history log and historyCache
+	historyCache   *historyCache
+	recordLocks    sync.Map   // Per-record ingest locks, keyed by record ID
+	ledgerMu       sync.Mutex // Guards the usage and generation ledgers
+	budgetMu       sync.Mutex // Serializes budget checks with usage recording
+	budgetHolds    map[*UsageEntry]bool
+	thumbLocks     sync.Map // Per-image thumbnail generation locks, keyed by cache key
+	thumbWarming   atomic.Bool
+	search         searchIndex
 }
 
 // NewApp creates a new App application struct
@@ -30,23 +46,22 @@ func NewApp() *App {
 func (a *App) OnStartup(ctx context.Context) {
 	a.ctx = ctx
 
-	// Get user config directory
-	configDir, err := os.UserConfigDir()
-	if err != nil {
-		// Fallback to current directory
-		wd, _ := os.Getwd()
-		configDir = wd
-	}
-
-	appDir := filepath.Join(configDir, "SparkPrompt")
-	if err := os.MkdirAll(appDir, 0755); err != nil {
+	// Resolve data root (portable mode, user-selected or default)
+	dataRoot := resolveDataRoot()
+	if err := os.MkdirAll(filepath.Join(dataRoot, imagesDirName), 0755); err != nil {
 		fmt.Printf("Warning: Failed to create app directory: %v\n", err)
 	}
-	a.configPath = filepath.Join(appDir, "config.json")
-	a.historyPath = filepath.Join(appDir, "ai-history.json")
-	a.templatesPath = filepath.Join(appDir, "templates.json")
-	a.banksPath = filepath.Join(appDir, "banks.json")
-	a.categoriesPath = filepath.Join(appDir, "categories.json")
+	a.setDataRoot(dataRoot)
+
+	// Import installed data on the first portable start and move images still
+	// referenced by absolute paths into the data root, once per data root
+	a.prepareDataRoot()
+
+	// Trim history in the background when the retention policy asks for it
+	go a.runRetentionAtStartup()
+
+	// Generate missing thumbnails for existing history
+	a.WarmUpThumbnails()
 }
 
 // OnDomReady is called after front-end resources have been loaded
@@ -0,0 +1,260 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// History Analytics Methods
+//
+// Generation counts, templates and bank options are computed from history records.
+// Success rates and latency come from generations.jsonl, which GenerateImage appends
+// to for every request, including failed ones and those refused before they were sent
+// (unknown provider, missing API key, budget used up).
+
+const (
+	// attemptLogFile is the append-only log of generation attempts under the data root
+	attemptLogFile = "generations.jsonl"
+	// defaultAnalyticsTop is the default length of the top template and option lists
+	defaultAnalyticsTop = 10
+)
+
+// GetHistoryAnalytics returns aggregate statistics over history and generation attempts
+func (a *App) GetHistoryAnalytics(query AnalyticsQuery) (*HistoryAnalytics, error) {
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	attempts, err := a.readGenerationAttempts()
+	if err != nil {
+		return nil, err
+	}
+
+	top := query.Top
+	if top <= 0 {
+		top = defaultAnalyticsTop
+	}
+
+	result := &HistoryAnalytics{}
+	perDay := make(map[string]*AnalyticsBucket)
+	perWeek := make(map[string]*AnalyticsBucket)
+	perProvider := make(map[string]*AnalyticsBucket)
+	templates := make(map[string]*AnalyticsBucket)
+	options := make(map[[2]string]int)
+
+	for _, record := range history {
+		if !matchAnalyticsQuery(record.Timestamp, record.Params.Provider, query) {
+			continue
+		}
+
+		images := len(record.Images)
+		result.Generations++
+		result.Images += images
+
+		t := time.Unix(record.Timestamp, 0)
+		year, week := t.ISOWeek()
+		countBucket(perDay, t.Format("2006-01-02"), images)
+		countBucket(perWeek, fmt.Sprintf("%d-W%02d", year, week), images)
+		countBucket(perProvider, record.Params.Provider, images)
+		if record.Params.TemplateID != "" {
+			countBucket(templates, record.Params.TemplateID, images)
+		}
+		for placeholder, value := range record.Params.Selections {
+			if value != "" {
+				options[[2]string{placeholder, value}]++
+			}
+		}
+	}
+
+	result.PerDay = sortedBuckets(perDay, false)
+	result.PerWeek = sortedBuckets(perWeek, false)
+	result.PerProvider = sortedBuckets(perProvider, true)
+	result.TopTemplates = truncateBuckets(sortedBuckets(templates, true), top)
+	result.TopBankOptions = topBankOptions(options, top)
+	result.Models = modelStats(attempts, query)
+
+	return result, nil
+}
+
+// Helper Methods
+
+// rejectGeneration records a request refused before it was sent and returns the refusal
+func (a *App) rejectGeneration(req *GenerateRequest, model string, resp *GenerateResponse) *GenerateResponse {
+	a.appendGenerationAttempt(req, model, resp, nil, 0, true)
+	return resp
+}
+
+// recordGenerationAttempt appends the outcome of a provider request to the attempt log
+func (a *App) recordGenerationAttempt(req *GenerateRequest, model string, resp *GenerateResponse, err error, latency time.Duration) {
+	a.appendGenerationAttempt(req, model, resp, err, latency, false)
+}
+
+// appendGenerationAttempt writes one attempt to the attempt log
+func (a *App) appendGenerationAttempt(req *GenerateRequest, model string, resp *GenerateResponse, err error, latency time.Duration, rejected bool) {
+	attempt := GenerationAttempt{
+		Timestamp:  time.Now().Unix(),
+		Provider:   req.Provider,
+		Model:      model,
+		LatencyMs:  latency.Milliseconds(),
+		TemplateID: req.TemplateID,
+		Rejected:   rejected,
+	}
+
+	switch {
+	case err != nil:
+		attempt.ErrorCode = "INTERNAL_ERROR"
+	case resp == nil:
+		attempt.ErrorCode = "NO_RESPONSE"
+	case resp.Success:
+		attempt.Success = true
+		attempt.Images = len(resp.Images)
+	case resp.Error != nil:
+		attempt.ErrorCode = resp.Error.Code
+	}
+
+	if err := a.appendLedgerLine(a.attemptsPath, attempt); err != nil {
+		fmt.Printf("Warning: Failed to record generation attempt: %v\n", err)
+	}
+}
+
+// readGenerationAttempts reads the attempt log, skipping lines that fail to parse
+func (a *App) readGenerationAttempts() ([]GenerationAttempt, error) {
+	attempts := make([]GenerationAttempt, 0)
+	err := a.readLedgerLines(a.attemptsPath, func(line []byte) {
+		var attempt GenerationAttempt
+		if json.Unmarshal(line, &attempt) == nil {
+			attempts = append(attempts, attempt)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read generation attempts: %w", err)
+	}
+	return attempts, nil
+}
+
+// modelStats aggregates attempts per provider and model
+func modelStats(attempts []GenerationAttempt, query AnalyticsQuery) []ModelStats {
+	stats := make(map[[2]string]*ModelStats)
+	latency := make(map[[2]string]int64)
+	dispatched := make(map[[2]string]int64)
+
+	for _, attempt := range attempts {
+		if !matchAnalyticsQuery(attempt.Timestamp, attempt.Provider, query) {
+			continue
+		}
+
+		key := [2]string{attempt.Provider, attempt.Model}
+		s, ok := stats[key]
+		if !ok {
+			s = &ModelStats{Provider: attempt.Provider, Model: attempt.Model}
+			stats[key] = s
+		}
+		s.Attempts++
+		if attempt.Rejected {
+			s.Rejected++
+		} else {
+			latency[key] += attempt.LatencyMs
+			dispatched[key]++
+		}
+		if attempt.Success {
+			s.Successes++
+			continue
+		}
+		s.Failures++
+		if attempt.ErrorCode != "" {
+			if s.ErrorCodes == nil {
+				s.ErrorCodes = make(map[string]int)
+			}
+			s.ErrorCodes[attempt.ErrorCode]++
+		}
+	}
+
+	result := make([]ModelStats, 0, len(stats))
+	for key, s := range stats {
+		s.SuccessRate = float64(s.Successes) / float64(s.Attempts)
+		if dispatched[key] > 0 {
+			s.AvgLatencyMs = latency[key] / dispatched[key]
+		}
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Attempts != result[j].Attempts {
+			return result[i].Attempts > result[j].Attempts
+		}
+		if result[i].Provider != result[j].Provider {
+			return result[i].Provider < result[j].Provider
+		}
+		return result[i].Model < result[j].Model
+	})
+	return result
+}
+
+// topBankOptions returns the most selected placeholder values
+func topBankOptions(options map[[2]string]int, top int) []BankOptionCount {
+	result := make([]BankOptionCount, 0, len(options))
+	for key, count := range options {
+		result = append(result, BankOptionCount{Placeholder: key[0], Value: key[1], Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		if result[i].Placeholder != result[j].Placeholder {
+			return result[i].Placeholder < result[j].Placeholder
+		}
+		return result[i].Value < result[j].Value
+	})
+
+	if len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+// matchAnalyticsQuery reports whether a timestamp and provider fall within the query
+func matchAnalyticsQuery(timestamp int64, provider string, query AnalyticsQuery) bool {
+	if query.From > 0 && timestamp < query.From {
+		return false
+	}
+	if query.To > 0 && timestamp >= query.To {
+		return false
+	}
+	return query.Provider == "" || provider == query.Provider
+}
+
+// countBucket adds one record with the given image count to a bucket
+func countBucket(buckets map[string]*AnalyticsBucket, key string, images int) {
+	bucket, ok := buckets[key]
+	if !ok {
+		bucket = &AnalyticsBucket{Key: key}
+		buckets[key] = bucket
+	}
+	bucket.Count++
+	bucket.Images += images
+}
+
+// sortedBuckets returns buckets ordered by key, or by count (descending) when byCount is set
+func sortedBuckets(buckets map[string]*AnalyticsBucket, byCount bool) []AnalyticsBucket {
+	result := make([]AnalyticsBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		result = append(result, *bucket)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if byCount && result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// truncateBuckets keeps at most n buckets
+func truncateBuckets(buckets []AnalyticsBucket, n int) []AnalyticsBucket {
+	if len(buckets) > n {
+		return buckets[:n]
+	}
+	return buckets
+}
@@ -0,0 +1,169 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Local Image Asset Handler
+//
+// The webview loads local images from /localimg/<ref> instead of receiving them as
+// base64 over the bridge. <ref> is an image store ID or a path relative to the data
+// root such as images/<id>.png; ?thumb=<size> serves a cached thumbnail instead.
+// Only image files inside the data root are served.
+
+const (
+	// localImagePrefix is the URL path prefix served by LocalImageHandler
+	localImagePrefix = "/localimg/"
+
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "no-cache"
+)
+
+// LocalImageHandler serves stored images and thumbnails to the asset server
+type LocalImageHandler struct {
+	app *App
+}
+
+// NewLocalImageHandler creates the asset server handler for local images
+func NewLocalImageHandler(app *App) *LocalImageHandler {
+	return &LocalImageHandler{app: app}
+}
+
+// ServeHTTP serves a local image with content type, caching headers and range support
+func (h *LocalImageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, localImagePrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Keep the data root in place while the file is located and served
+	h.app.rootMu.RLock()
+	defer h.app.rootMu.RUnlock()
+
+	ref, immutable, err := h.app.localImageRef(strings.TrimPrefix(r.URL.Path, localImagePrefix))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	filePath := h.app.resolveImagePath(ref)
+	if thumb := r.URL.Query().Get("thumb"); thumb != "" {
+		size, err := strconv.Atoi(thumb)
+		if err != nil || size <= 0 {
+			http.Error(w, "invalid thumbnail size", http.StatusBadRequest)
+			return
+		}
+		if filePath, err = h.app.ensureThumbnail(ref, size); err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		// Thumbnails are regenerated when the source changes
+		immutable = false
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", imageContentType(filePath))
+	header.Set("X-Content-Type-Options", "nosniff")
+	if immutable {
+		header.Set("Cache-Control", immutableCacheControl)
+		header.Set("ETag", fmt.Sprintf(`"%s"`, filepath.Base(filePath)))
+	} else {
+		header.Set("Cache-Control", revalidateCacheControl)
+	}
+
+	// ServeContent handles Range, If-Modified-Since and If-None-Match
+	http.ServeContent(w, r, filepath.Base(filePath), info.ModTime(), file)
+}
+
+// Helper Methods
+
+// localImageRef validates a requested image and returns its data root relative
+// reference. Stored images are content-addressed, so they are reported as immutable.
+func (a *App) localImageRef(requested string) (string, bool, error) {
+	if a.dataRoot == "" {
+		return "", false, fmt.Errorf("data root not initialized")
+	}
+
+	// Backslashes would act as separators on Windows after cleaning
+	if strings.Contains(requested, "\\") {
+		return "", false, fmt.Errorf("invalid image path: %s", requested)
+	}
+
+	// A bare image store ID
+	if !strings.Contains(requested, "/") && imageIDFromRef(requested+".png") != "" {
+		info, err := a.GetImageInfo(requested)
+		if err != nil {
+			return "", false, err
+		}
+		return info.Path, true, nil
+	}
+
+	// A path relative to the data root; cleaning a rooted path removes any ".."
+	rel := strings.TrimPrefix(path.Clean("/"+requested), "/")
+	if rel == "" || !isImageFileName(rel) {
+		return "", false, fmt.Errorf("not an image: %s", requested)
+	}
+
+	abs := filepath.Join(a.dataRoot, filepath.FromSlash(rel))
+	if !a.insideDataRoot(abs) {
+		return "", false, fmt.Errorf("outside the data root: %s", requested)
+	}
+
+	return rel, imageIDFromRef(rel) != "", nil
+}
+
+// insideDataRoot reports whether path, with symlinks resolved, lies within the data root
+func (a *App) insideDataRoot(p string) bool {
+	root, err := filepath.EvalSymlinks(a.dataRoot)
+	if err != nil {
+		return false
+	}
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// imageContentType returns the MIME type for an image file name
+func imageContentType(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".webp":
+		return "image/webp"
+	case ".gif":
+		return "image/gif"
+	default:
+		return "image/png"
+	}
+}
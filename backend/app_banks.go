@@ -41,6 +41,20 @@ func (a *App) LoadBanks() (BankMap, error) {
 
 // SaveBanks saves vocab banks to file system
 func (a *App) SaveBanks(banks BankMap) error {
+	if err := a.writeBanks(banks); err != nil {
+		return err
+	}
+
+	docs := make([]*searchDoc, 0, len(banks))
+	for key, bank := range banks {
+		docs = append(docs, bankSearchDoc(key, bank))
+	}
+	a.search.replaceKind(searchKindBank, docs)
+	return nil
+}
+
+// writeBanks writes banks.json without touching the search index
+func (a *App) writeBanks(banks BankMap) error {
 	data, err := json.MarshalIndent(banks, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal banks: %w", err)
@@ -56,7 +70,12 @@ func (a *App) EnsureBank(key string, item BankItem) error {
 	}
 
 	banks[key] = item
-	return a.SaveBanks(banks)
+	if err := a.writeBanks(banks); err != nil {
+		return err
+	}
+
+	a.search.put(bankSearchDoc(key, item))
+	return nil
 }
 
 // DeleteBank deletes a bank by key
@@ -67,5 +86,10 @@ func (a *App) DeleteBank(key string) error {
 	}
 
 	delete(banks, key)
-	return a.SaveBanks(banks)
+	if err := a.writeBanks(banks); err != nil {
+		return err
+	}
+
+	a.search.remove(searchKindBank, key)
+	return nil
 }
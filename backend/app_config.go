@@ -6,6 +6,7 @@ import (
 	"os"
 	"time"
 	"path/filepath"
+	"sort"
 )
 
 // Configuration Management Methods
@@ -101,6 +102,8 @@ func (a *App) loadOrCreateConfig() (*Configuration, error) {
             }
 
             // 3. 确保目录存在并将默认配置写入磁盘，以便用户后续自定义
+            //    同时记录已合并的内置项，用户删除的服务商和模型不会再被补回
+            embeddedData = mergeDefaultProviders(embeddedData)
             if err := os.MkdirAll(filepath.Dir(a.configPath), 0755); err == nil {
                 _ = os.WriteFile(a.configPath, embeddedData, 0644)
             }
@@ -112,7 +115,10 @@ func (a *App) loadOrCreateConfig() (*Configuration, error) {
         }
     }
 
-    // 4. 解析 JSON 数据
+    // 4. 补全内置配置中新增的服务商、模型和能力（仅在内存中，保存配置时写回）
+    data = mergeDefaultProviders(data)
+
+    // 5. 解析 JSON 数据
     var config Configuration
     if err := json.Unmarshal(data, &config); err != nil {
         // 如果解析失败（可能是文件损坏），返回错误或默认配置
@@ -157,3 +163,142 @@ func (a *App) maskAPIKey(apiKey string) string {
 	}
 	return "xxxxx"
 }
+
+// mergeDefaultProviders fills in the providers, models and capability fields of the
+// embedded configuration that are new since the saved configuration was last merged,
+// so models added in an update show up. mergedDefaults in the configuration lists
+// what has been offered before; those entries are never added again, so providers and
+// models the user removed stay removed. Values in the saved configuration are kept.
+// The result is only returned; it reaches disk the next time the configuration is saved.
+func mergeDefaultProviders(data []byte) []byte {
+	embeddedData, err := defaultConfigFS.ReadFile("json/ai-providers.json")
+	if err != nil {
+		return data
+	}
+
+	var config, defaults map[string]any
+	if json.Unmarshal(data, &config) != nil || json.Unmarshal(embeddedData, &defaults) != nil {
+		// Parse errors are reported when the configuration is decoded
+		return data
+	}
+
+	offered := make(map[string]bool)
+	list, _ := config["mergedDefaults"].([]any)
+	for _, key := range list {
+		if key, ok := key.(string); ok {
+			offered[key] = true
+		}
+	}
+
+	providers, _ := config["providers"].(map[string]any)
+	if providers == nil {
+		providers = make(map[string]any)
+		config["providers"] = providers
+	}
+	defaultProviders, _ := defaults["providers"].(map[string]any)
+
+	changed := false
+	offer := func(key string) {
+		if !offered[key] {
+			offered[key] = true
+			list = append(list, key)
+			changed = true
+		}
+	}
+
+	for _, id := range sortedJSONKeys(defaultProviders) {
+		defaultFields, _ := defaultProviders[id].(map[string]any)
+		provider, ok := providers[id].(map[string]any)
+		if !ok && providers[id] == nil && !offered[id] {
+			providers[id] = defaultProviders[id]
+		}
+		offer(id)
+
+		defaultModels, _ := defaultFields["models"].([]any)
+		defaultCaps, _ := defaultFields["modelCapabilities"].(map[string]any)
+		for _, model := range defaultModels {
+			name, _ := model.(string)
+			if name == "" {
+				continue
+			}
+			modelKey := id + "/" + name
+
+			if ok && !offered[modelKey] {
+				models, _ := provider["models"].([]any)
+				if !containsJSONString(models, name) {
+					provider["models"] = append(models, name)
+				}
+				for _, field := range []string{"sizeOptions", "requestTemplate", "modelCapabilities"} {
+					fillModelEntry(provider, defaultFields, field, name)
+				}
+			}
+			offer(modelKey)
+
+			// Capability fields added to a model after it was first offered
+			caps, _ := defaultCaps[name].(map[string]any)
+			for _, field := range sortedJSONKeys(caps) {
+				fieldKey := modelKey + "/" + field
+				if ok && !offered[fieldKey] {
+					if entries, _ := provider["modelCapabilities"].(map[string]any); entries != nil {
+						if existing, _ := entries[name].(map[string]any); existing != nil {
+							if _, exists := existing[field]; !exists {
+								existing[field] = caps[field]
+							}
+						}
+					}
+				}
+				offer(fieldKey)
+			}
+		}
+	}
+
+	if !changed {
+		return data
+	}
+	config["mergedDefaults"] = list
+
+	merged, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return data
+	}
+	return merged
+}
+
+// fillModelEntry copies defaults[field][model] into provider[field] when it is missing
+func fillModelEntry(provider, defaults map[string]any, field, model string) {
+	value, exists := defaults[field].(map[string]any)[model]
+	if !exists {
+		return
+	}
+	entries, ok := provider[field].(map[string]any)
+	if !ok {
+		if provider[field] != nil {
+			return
+		}
+		entries = make(map[string]any)
+		provider[field] = entries
+	}
+	if _, exists := entries[model]; !exists {
+		entries[model] = value
+	}
+}
+
+// sortedJSONKeys returns the keys of a JSON object in order, so merges are recorded deterministically
+func sortedJSONKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// containsJSONString reports whether values contains the string s
+func containsJSONString(values []any, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
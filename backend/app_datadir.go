@@ -0,0 +1,632 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Data Root Management Methods
+
+const (
+	// appDirName is the folder created under the user config directory
+	appDirName = "SparkPrompt"
+	// portableMarker enables portable mode when present next to the executable
+	portableMarker = "portable"
+	// portableDataDir is the data folder used in portable mode, relative to the executable
+	portableDataDir = "data"
+	// dataRootPointerFile stores a user-selected data root in the default app directory
+	dataRootPointerFile = "dataroot.json"
+	// dataRootStateFile records the one-time migrations already done in a data root
+	dataRootStateFile = "datastate.json"
+	// imagesDirName is the folder under the data root holding all stored images
+	imagesDirName = "images"
+)
+
+// dataFiles lists the JSON files that live directly under the data root
+var dataFiles = []string{"config.json", historyLogFile, legacyHistoryFile, "templates.json", "banks.json", "categories.json", imageIndexFile, usageLedgerFile, attemptLogFile, dataRootStateFile}
+
+// dataRootPointer is the on-disk format of dataroot.json
+type dataRootPointer struct {
+	DataRoot string `json:"dataRoot"`
+}
+
+// dataRootState is the on-disk format of datastate.json
+type dataRootState struct {
+	ImagesMigrated bool   `json:"imagesMigrated,omitempty"` // Absolute image paths were moved in
+	ImagesAdopted  bool   `json:"imagesAdopted,omitempty"`  // Images outside the store were added to it
+	ImportedFrom   string `json:"importedFrom,omitempty"`   // Data root copied in on the first portable start
+}
+
+// GetDataRoot returns information about the current data root
+func (a *App) GetDataRoot() (*DataRootInfo, error) {
+	if a.dataRoot == "" {
+		return nil, fmt.Errorf("data root not initialized")
+	}
+
+	return &DataRootInfo{
+		Path:         a.dataRoot,
+		ImagesDir:    a.imagesDir(),
+		Portable:     isPortableMode(),
+		Custom:       !isPortableMode() && a.dataRoot != defaultAppDir(),
+		ImportedFrom: a.loadDataRootState().ImportedFrom,
+	}, nil
+}
+
+// SetDataRoot moves all application data to a new root directory and remembers the choice.
+// An empty path resets the data root to the default location. A target that already
+// holds data is refused, and a failed move puts everything back in the old root.
+func (a *App) SetDataRoot(path string) (*ImageMigrationReport, error) {
+	if isPortableMode() {
+		return nil, fmt.Errorf("data root cannot be changed in portable mode")
+	}
+
+	newRoot := defaultAppDir()
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data root: %w", err)
+		}
+		newRoot = abs
+	}
+
+	if newRoot == a.dataRoot {
+		return &ImageMigrationReport{}, nil
+	}
+	if existing := dataRootContents(newRoot); len(existing) > 0 {
+		return nil, fmt.Errorf("data root already contains data: %s", strings.Join(existing, ", "))
+	}
+
+	// Pull images still referenced by absolute path into the current root first, so
+	// they move with the rest of the images
+	report, err := a.MigrateImages()
+	if err != nil {
+		return nil, err
+	}
+	a.markImagesMigrated(report)
+
+	if err := os.MkdirAll(filepath.Join(newRoot, imagesDirName), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data root: %w", err)
+	}
+
+	// Nothing may read or write data files while they move
+	a.rootMu.Lock()
+	defer a.rootMu.Unlock()
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	oldRoot := a.dataRoot
+	var moves []movedFile
+
+	for _, name := range dataFiles {
+		src := filepath.Join(oldRoot, name)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		dst := filepath.Join(newRoot, name)
+		if err := moveFile(src, dst); err != nil {
+			undoMoves(moves)
+			return nil, fmt.Errorf("failed to move %s: %w", name, err)
+		}
+		moves = append(moves, movedFile{from: src, to: dst})
+	}
+
+	// Move the image folder contents; relative history references stay valid
+	movedImages, err := moveDirContents(filepath.Join(oldRoot, imagesDirName), filepath.Join(newRoot, imagesDirName))
+	moves = append(moves, movedImages...)
+	if err != nil {
+		undoMoves(moves)
+		return nil, fmt.Errorf("failed to move images: %w", err)
+	}
+	report.Moved += len(movedImages)
+
+	if err := writeDataRootPointer(path); err != nil {
+		undoMoves(moves)
+		return nil, err
+	}
+
+	a.setDataRootLocked(newRoot)
+	return report, nil
+}
+
+// MigrateImages moves images referenced by absolute paths in history and templates
+// into the data root images folder and rewrites the references as relative paths
+func (a *App) MigrateImages() (*ImageMigrationReport, error) {
+	if err := os.MkdirAll(a.imagesDir(), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create images directory: %w", err)
+	}
+
+	report := &ImageMigrationReport{}
+
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	historyChanged := false
+	for i := range history {
+		for j := range history[i].Images {
+			if ref, ok := a.migrateImageRef(history[i].Images[j].URL, report); ok {
+				history[i].Images[j].URL = ref
+				historyChanged = true
+			}
+		}
+	}
+
+	if historyChanged {
+		if err := a.SaveAIHistory(history); err != nil {
+			return nil, fmt.Errorf("failed to save history: %w", err)
+		}
+	}
+
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	templatesChanged := false
+	for i := range templates {
+		if ref, ok := a.migrateImageRef(templates[i].ImageURL, report); ok {
+			templates[i].ImageURL = ref
+			templatesChanged = true
+		}
+		for j := range templates[i].ImageURLs {
+			if ref, ok := a.migrateImageRef(templates[i].ImageURLs[j], report); ok {
+				templates[i].ImageURLs[j] = ref
+				templatesChanged = true
+			}
+		}
+	}
+
+	if templatesChanged {
+		if err := a.SaveTemplates(templates); err != nil {
+			return nil, fmt.Errorf("failed to save templates: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+// migrateImageRef moves a single absolute image reference into the images folder.
+// It returns the new relative reference and true when the reference should be rewritten.
+func (a *App) migrateImageRef(ref string, report *ImageMigrationReport) (string, bool) {
+	if !isLocalImageRef(ref) || !filepath.IsAbs(ref) {
+		return "", false
+	}
+
+	// Already inside the data root, only the reference needs rewriting
+	if rel, ok := a.relativeToDataRoot(ref); ok {
+		report.RewrittenPaths++
+		return rel, true
+	}
+
+	if _, err := os.Stat(ref); err != nil {
+		report.Missing = append(report.Missing, ref)
+		return "", false
+	}
+
+	dst := uniquePath(filepath.Join(a.imagesDir(), filepath.Base(ref)))
+	if err := moveFile(ref, dst); err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", ref, err))
+		return "", false
+	}
+
+	report.Moved++
+	report.RewrittenPaths++
+	return a.toImageRef(dst), true
+}
+
+// Helper Methods
+
+// prepareDataRoot runs the one-time migrations of the current data root at startup.
+// On the first portable start the installed data is copied in, images still
+// referenced by absolute paths are moved into the data root, and images outside the
+// store are added to it. A corrupt image index is rebuilt whenever it is found.
+func (a *App) prepareDataRoot() {
+	state := a.loadDataRootState()
+
+	if isPortableMode() && state.ImportedFrom == "" && !a.hasData() {
+		from := installedDataRoot()
+		if from != a.dataRoot {
+			copied, errs := importDataRoot(from, a.dataRoot)
+			for _, e := range errs {
+				fmt.Printf("Warning: Failed to import %s\n", e)
+			}
+			if copied > 0 {
+				fmt.Printf("Imported %d files from %s\n", copied, from)
+				state.ImportedFrom = from
+				if err := a.saveDataRootState(state); err != nil {
+					fmt.Printf("Warning: Failed to save data state: %v\n", err)
+				}
+			}
+		}
+	}
+
+	if !state.ImagesMigrated {
+		report, err := a.MigrateImages()
+		if err != nil {
+			fmt.Printf("Warning: Failed to migrate images: %v\n", err)
+			return
+		}
+		if report.Moved > 0 || len(report.Errors) > 0 {
+			fmt.Printf("Migrated %d images into %s (%d errors)\n", report.Moved, a.imagesDir(), len(report.Errors))
+		}
+		a.markImagesMigrated(report)
+	}
+
+	if !a.loadDataRootState().ImagesAdopted || a.imageIndexCorrupt() {
+		report, err := a.RebuildImageIndex()
+		if err != nil {
+			fmt.Printf("Warning: Failed to rebuild image index: %v\n", err)
+			return
+		}
+		if report.Moved > 0 || len(report.Errors) > 0 {
+			fmt.Printf("Added %d images to the image store (%d errors)\n", report.Moved, len(report.Errors))
+		}
+		if len(report.Errors) == 0 {
+			state := a.loadDataRootState()
+			state.ImagesAdopted = true
+			if err := a.saveDataRootState(state); err != nil {
+				fmt.Printf("Warning: Failed to save data state: %v\n", err)
+			}
+		}
+	}
+}
+
+// markImagesMigrated records that MigrateImages has run for the current data root,
+// unless some images failed to move and should be retried on the next start.
+// Missing images are not retried.
+func (a *App) markImagesMigrated(report *ImageMigrationReport) {
+	if len(report.Errors) > 0 {
+		return
+	}
+	state := a.loadDataRootState()
+	state.ImagesMigrated = true
+	if err := a.saveDataRootState(state); err != nil {
+		fmt.Printf("Warning: Failed to save data state: %v\n", err)
+	}
+}
+
+// loadDataRootState reads datastate.json, returning the zero state if it is missing
+// or unreadable so migrations run again rather than being skipped
+func (a *App) loadDataRootState() dataRootState {
+	var state dataRootState
+	data, err := os.ReadFile(filepath.Join(a.dataRoot, dataRootStateFile))
+	if err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// saveDataRootState writes datastate.json
+func (a *App) saveDataRootState(state dataRootState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data state: %w", err)
+	}
+	return os.WriteFile(filepath.Join(a.dataRoot, dataRootStateFile), data, 0644)
+}
+
+// hasData reports whether any data file exists in the current data root
+func (a *App) hasData() bool {
+	for _, name := range dataFiles {
+		if _, err := os.Stat(filepath.Join(a.dataRoot, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// setDataRoot points all data file paths at the given root
+func (a *App) setDataRoot(root string) {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	a.setDataRootLocked(root)
+}
+
+// setDataRootLocked is setDataRoot for callers holding historyMu
+func (a *App) setDataRootLocked(root string) {
+	a.dataRoot = root
+	a.configPath = filepath.Join(root, "config.json")
+	a.historyPath = filepath.Join(root, historyLogFile)
+	a.templatesPath = filepath.Join(root, "templates.json")
+	a.banksPath = filepath.Join(root, "banks.json")
+	a.categoriesPath = filepath.Join(root, "categories.json")
+	a.usagePath = filepath.Join(root, usageLedgerFile)
+	a.attemptsPath = filepath.Join(root, attemptLogFile)
+	a.search.reset()
+	a.historyCache = nil
+}
+
+// dataRootContents lists the data files found in root, and the images folder when
+// any file lies below it. Empty folders, such as those left by an undone move, don't count.
+func dataRootContents(root string) []string {
+	var existing []string
+	for _, name := range dataFiles {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			existing = append(existing, name)
+		}
+	}
+
+	hasImages := false
+	_ = filepath.WalkDir(filepath.Join(root, imagesDirName), func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			hasImages = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if hasImages {
+		existing = append(existing, imagesDirName)
+	}
+	return existing
+}
+
+// imagesDir returns the directory where images are stored
+func (a *App) imagesDir() string {
+	return filepath.Join(a.dataRoot, imagesDirName)
+}
+
+// resolveImagePath turns a stored image reference into an absolute file path.
+// Remote URLs, data URIs and absolute paths are returned unchanged.
+func (a *App) resolveImagePath(ref string) string {
+	if !isLocalImageRef(ref) || filepath.IsAbs(ref) {
+		return ref
+	}
+	return filepath.Join(a.dataRoot, filepath.FromSlash(ref))
+}
+
+// toImageRef converts an absolute file path into the reference stored in history,
+// relative to the data root when possible
+func (a *App) toImageRef(path string) string {
+	if rel, ok := a.relativeToDataRoot(path); ok {
+		return rel
+	}
+	return path
+}
+
+// relativeToDataRoot returns path relative to the data root using forward slashes,
+// or false if path lies outside of it
+func (a *App) relativeToDataRoot(path string) (string, bool) {
+	rel, err := filepath.Rel(a.dataRoot, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// resolveDataRoot determines the data root: portable mode first, then a user-selected
+// root from dataroot.json, and finally the default app directory
+func resolveDataRoot() string {
+	if isPortableMode() {
+		if execDir, err := executableDir(); err == nil {
+			return filepath.Join(execDir, portableDataDir)
+		}
+	}
+	return installedDataRoot()
+}
+
+// installedDataRoot returns the data root used outside portable mode: the root
+// from dataroot.json, or the default app directory
+func installedDataRoot() string {
+	data, err := os.ReadFile(filepath.Join(defaultAppDir(), dataRootPointerFile))
+	if err == nil {
+		var pointer dataRootPointer
+		if json.Unmarshal(data, &pointer) == nil && pointer.DataRoot != "" {
+			return pointer.DataRoot
+		}
+	}
+
+	return defaultAppDir()
+}
+
+// writeDataRootPointer stores the user-selected data root, or removes it when path is empty
+func writeDataRootPointer(path string) error {
+	pointerPath := filepath.Join(defaultAppDir(), dataRootPointerFile)
+	if path == "" {
+		if err := os.Remove(pointerPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to reset data root: %w", err)
+		}
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid data root: %w", err)
+	}
+
+	data, err := json.MarshalIndent(dataRootPointer{DataRoot: abs}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal data root: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(pointerPath), 0755); err != nil {
+		return fmt.Errorf("failed to create app directory: %w", err)
+	}
+	return os.WriteFile(pointerPath, data, 0644)
+}
+
+// defaultAppDir returns <UserConfigDir>/SparkPrompt, falling back to the working directory
+func defaultAppDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		wd, _ := os.Getwd()
+		configDir = wd
+	}
+	return filepath.Join(configDir, appDirName)
+}
+
+// executableDir returns the directory containing the running binary
+func executableDir() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(execPath), nil
+}
+
+// isPortableMode reports whether the portable marker file sits next to the executable
+func isPortableMode() bool {
+	execDir, err := executableDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(execDir, portableMarker))
+	return err == nil
+}
+
+// isLocalImageRef reports whether an image reference points to a local file
+func isLocalImageRef(ref string) bool {
+	return ref != "" &&
+		!strings.HasPrefix(ref, "http://") &&
+		!strings.HasPrefix(ref, "https://") &&
+		!strings.HasPrefix(ref, "data:")
+}
+
+// uniquePath appends a counter to path until no file exists at that location
+func uniquePath(path string) string {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// movedFile is one file moved by a data root switch, kept so the move can be undone
+type movedFile struct {
+	from string
+	to   string
+}
+
+// moveDirContents moves every file below src into dst, keeping subfolders. It stops
+// at the first file that fails and returns the files moved so far.
+func moveDirContents(src, dst string) ([]movedFile, error) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var moves []movedFile
+	for _, entry := range entries {
+		from := filepath.Join(src, entry.Name())
+		to := filepath.Join(dst, entry.Name())
+		if entry.IsDir() {
+			if err := os.MkdirAll(to, 0755); err != nil {
+				return moves, err
+			}
+			sub, err := moveDirContents(from, to)
+			moves = append(moves, sub...)
+			if err != nil {
+				return moves, err
+			}
+			continue
+		}
+		if _, err := os.Stat(to); err == nil {
+			return moves, fmt.Errorf("%s: already exists in destination", to)
+		}
+		if err := moveFile(from, to); err != nil {
+			return moves, fmt.Errorf("%s: %w", from, err)
+		}
+		moves = append(moves, movedFile{from: from, to: to})
+	}
+	return moves, nil
+}
+
+// undoMoves moves files back to where they came from, newest first
+func undoMoves(moves []movedFile) {
+	for i := len(moves) - 1; i >= 0; i-- {
+		if err := moveFile(moves[i].to, moves[i].from); err != nil {
+			fmt.Printf("Warning: Failed to move %s back to %s: %v\n", moves[i].to, moves[i].from, err)
+		}
+	}
+}
+
+// importDataRoot copies the data files and stored images of src into dst, leaving
+// src untouched. Files that already exist in dst are kept.
+func importDataRoot(src, dst string) (int, []string) {
+	if err := os.MkdirAll(filepath.Join(dst, imagesDirName), 0755); err != nil {
+		return 0, []string{fmt.Sprintf("%s: %v", dst, err)}
+	}
+
+	copied := 0
+	var errs []string
+	copyMissing := func(from, to string) {
+		if _, err := os.Stat(to); err == nil {
+			return
+		}
+		if err := copyFile(from, to); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", from, err))
+			return
+		}
+		copied++
+	}
+
+	for _, name := range dataFiles {
+		if name == dataRootStateFile {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(src, name)); err == nil {
+			copyMissing(filepath.Join(src, name), filepath.Join(dst, name))
+		}
+	}
+
+	entries, _ := os.ReadDir(filepath.Join(src, imagesDirName))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			copyMissing(filepath.Join(src, imagesDirName, entry.Name()), filepath.Join(dst, imagesDirName, entry.Name()))
+		}
+	}
+	return copied, errs
+}
+
+// moveFile renames src to dst, copying across devices when a rename is not possible
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyFile copies src to dst, removing dst again if the copy fails
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return nil
+}
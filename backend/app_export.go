@@ -0,0 +1,466 @@
+package backend
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// History Export Methods
+
+// galleryTemplate renders the offline index.html shipped with an export
+var galleryTemplate = template.Must(template.New("gallery").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; padding: 24px; font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; background: #1b2636; color: #e5e7eb; }
+h1 { font-size: 20px; font-weight: 600; margin: 0 0 4px; }
+.meta { color: #9ca3af; font-size: 13px; margin-bottom: 24px; }
+.record { display: flex; gap: 24px; padding: 20px; margin-bottom: 16px; background: #243244; border-radius: 12px; }
+.images { flex: 0 0 45%; display: flex; flex-wrap: wrap; gap: 8px; }
+.images img { max-width: 100%; border-radius: 8px; }
+.missing { padding: 24px; border: 1px dashed #4b5563; border-radius: 8px; color: #9ca3af; font-size: 13px; }
+.details { flex: 1; min-width: 0; }
+.prompt { white-space: pre-wrap; line-height: 1.6; margin: 0 0 12px; }
+.params { font-size: 13px; color: #9ca3af; }
+.params span { display: inline-block; margin: 0 12px 4px 0; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">{{len .Records}} records · exported {{.ExportedAt}}</div>
+{{range .Records}}
+<div class="record">
+  <div class="images">
+    {{range .Images}}<a href="{{.}}"><img src="{{.}}" alt=""></a>{{else}}<div class="missing">Image not available</div>{{end}}
+  </div>
+  <div class="details">
+    <p class="prompt">{{.Prompt}}</p>
+    {{if .NegativePrompt}}<p class="params">Negative: {{.NegativePrompt}}</p>{{end}}
+    <div class="params">
+      <span>{{.Provider}} / {{.Model}}</span>
+      {{if .Size}}<span>Size: {{.Size}}</span>{{end}}
+      {{if .Seed}}<span>Seed: {{.Seed}}</span>{{end}}
+      <span>{{.Date}}</span>
+    </div>
+  </div>
+</div>
+{{end}}
+</body>
+</html>
+`))
+
+// galleryRecord is a history record as shown in index.html
+type galleryRecord struct {
+	Prompt         string
+	NegativePrompt string
+	Provider       string
+	Model          string
+	Size           string
+	Seed           string
+	Date           string
+	Images         []string
+}
+
+// manifestRecord is a history record as written to manifest.json
+type manifestRecord struct {
+	ID        string           `json:"id"`
+	Timestamp int64            `json:"timestamp"`
+	Params    GenerationParams `json:"params"`
+	Images    []string         `json:"images"` // Paths inside the export
+}
+
+// ExportHistory exports history records to a folder or zip archive containing the
+// images, a JSON and CSV manifest, and an offline index.html gallery.
+// Missing images are reported in the result instead of failing the export.
+func (a *App) ExportHistory(options HistoryExportOptions) (*HistoryExportResult, error) {
+	records, err := a.selectHistoryRecords(options.RecordIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	asZip := options.Format == "zip"
+	destination := options.Destination
+	if destination == "" {
+		destination, err = a.chooseExportDestination(asZip)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sink exportSink
+	if asZip {
+		sink, err = newZipSink(destination)
+	} else {
+		sink, err = newFolderSink(destination)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &HistoryExportResult{Path: destination, Missing: []ExportIssue{}}
+	manifest := make([]manifestRecord, 0, len(records))
+	gallery := make([]galleryRecord, 0, len(records))
+
+	for _, record := range records {
+		entry := manifestRecord{ID: record.ID, Timestamp: record.Timestamp, Params: record.Params, Images: []string{}}
+
+		for i, img := range record.Images {
+			data, ext, err := a.readExportImage(img.URL)
+			if err != nil {
+				result.Missing = append(result.Missing, ExportIssue{RecordID: record.ID, Path: img.URL, Reason: err.Error()})
+				continue
+			}
+
+			name := fmt.Sprintf("images/%s_%d%s", sanitizeFileName(record.ID), i, ext)
+			if err := sink.writeFile(name, data); err != nil {
+				sink.abort()
+				return nil, fmt.Errorf("failed to write %s: %w", name, err)
+			}
+			entry.Images = append(entry.Images, name)
+			result.Images++
+		}
+
+		manifest = append(manifest, entry)
+		gallery = append(gallery, newGalleryRecord(record, entry.Images))
+		result.Records++
+	}
+
+	if err := writeExportManifest(sink, manifest); err != nil {
+		sink.abort()
+		return nil, err
+	}
+
+	var page bytes.Buffer
+	err = galleryTemplate.Execute(&page, map[string]interface{}{
+		"Title":      "SparkPrompt Gallery",
+		"ExportedAt": time.Now().Format("2006-01-02 15:04"),
+		"Records":    gallery,
+	})
+	if err != nil {
+		sink.abort()
+		return nil, fmt.Errorf("failed to render gallery: %w", err)
+	}
+	if err := sink.writeFile("index.html", page.Bytes()); err != nil {
+		sink.abort()
+		return nil, fmt.Errorf("failed to write gallery: %w", err)
+	}
+
+	if err := sink.close(); err != nil {
+		return nil, fmt.Errorf("failed to finish export: %w", err)
+	}
+
+	return result, nil
+}
+
+// Helper Methods
+
+// selectHistoryRecords returns the records with the given IDs in history order,
+// or all records when ids is empty
+func (a *App) selectHistoryRecords(ids []string) ([]HistoryRecord, error) {
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return history, nil
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	selected := make([]HistoryRecord, 0, len(ids))
+	for _, record := range history {
+		if wanted[record.ID] {
+			selected = append(selected, record)
+		}
+	}
+	return selected, nil
+}
+
+// chooseExportDestination asks the user for an export folder or zip file path
+func (a *App) chooseExportDestination(asZip bool) (string, error) {
+	if a.ctx == nil {
+		return "", fmt.Errorf("application context not available")
+	}
+
+	downloadDir, err := a.GetUserDownloadDir()
+	if err != nil {
+		downloadDir = "."
+	}
+
+	var path string
+	if asZip {
+		path, err = wailsruntime.SaveFileDialog(a.ctx, wailsruntime.SaveDialogOptions{
+			DefaultFilename:  fmt.Sprintf("spark-prompt-export-%s.zip", time.Now().Format("20060102-150405")),
+			DefaultDirectory: downloadDir,
+			Title:            "Export History",
+			Filters: []wailsruntime.FileFilter{
+				{
+					DisplayName: "Zip Archives",
+					Pattern:     "*.zip",
+				},
+			},
+		})
+	} else {
+		path, err = wailsruntime.OpenDirectoryDialog(a.ctx, wailsruntime.OpenDialogOptions{
+			DefaultDirectory:     downloadDir,
+			Title:                "Export History",
+			CanCreateDirectories: true,
+		})
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to show export dialog: %w", err)
+	}
+	if path == "" {
+		return "", fmt.Errorf("export cancelled by user")
+	}
+	return path, nil
+}
+
+// readExportImage loads the bytes behind an image reference along with a file extension
+func (a *App) readExportImage(ref string) ([]byte, string, error) {
+	switch {
+	case ref == "":
+		return nil, "", fmt.Errorf("empty image path")
+
+	case strings.HasPrefix(ref, "data:"):
+		parts := strings.SplitN(ref, ",", 2)
+		if len(parts) != 2 {
+			return nil, "", fmt.Errorf("invalid data URI")
+		}
+		data, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decode base64 data: %w", err)
+		}
+		format, _, _ := detectImageFormat(data)
+		return data, imageExtension(format), nil
+
+	case !isLocalImageRef(ref):
+		return nil, "", fmt.Errorf("remote image was never saved locally")
+
+	default:
+		path := a.resolveImagePath(ref)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, "", fmt.Errorf("image file not found")
+			}
+			return nil, "", err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == "" {
+			format, _, _ := detectImageFormat(data)
+			ext = imageExtension(format)
+		}
+		return data, ext, nil
+	}
+}
+
+// newGalleryRecord prepares a record for the gallery page
+func newGalleryRecord(record HistoryRecord, images []string) galleryRecord {
+	entry := galleryRecord{
+		Prompt:         record.Params.Prompt,
+		NegativePrompt: record.Params.NegativePrompt,
+		Provider:       record.Params.Provider,
+		Model:          record.Params.Model,
+		Size:           record.Params.Size,
+		Date:           time.Unix(record.Timestamp, 0).Format("2006-01-02 15:04"),
+		Images:         images,
+	}
+	if record.Params.Seed != nil {
+		entry.Seed = strconv.FormatInt(*record.Params.Seed, 10)
+	}
+	return entry
+}
+
+// writeExportManifest writes manifest.json and manifest.csv
+func writeExportManifest(sink exportSink, manifest []manifestRecord) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	if err := sink.writeFile("manifest.json", data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"id", "timestamp", "prompt", "negativePrompt", "provider", "model", "size", "seed", "templateId", "images"})
+	for _, entry := range manifest {
+		seed := ""
+		if entry.Params.Seed != nil {
+			seed = strconv.FormatInt(*entry.Params.Seed, 10)
+		}
+		w.Write([]string{
+			csvCell(entry.ID),
+			strconv.FormatInt(entry.Timestamp, 10),
+			csvCell(entry.Params.Prompt),
+			csvCell(entry.Params.NegativePrompt),
+			csvCell(entry.Params.Provider),
+			csvCell(entry.Params.Model),
+			csvCell(entry.Params.Size),
+			seed,
+			csvCell(entry.Params.TemplateID),
+			csvCell(strings.Join(entry.Images, ";")),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	// Prefix a UTF-8 BOM so spreadsheet apps detect the encoding of Chinese prompts
+	return sink.writeFile("manifest.csv", append([]byte("\ufeff"), buf.Bytes()...))
+}
+
+// csvCell prefixes text that a spreadsheet would run as a formula with a quote,
+// so prompts starting with "=", "+", "-" or "@" are shown as text
+func csvCell(text string) string {
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
+
+// windowsReservedNames are device names Windows refuses as file names, with or
+// without an extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeFileName replaces characters that are not safe in file names, drops the
+// trailing dots and spaces Windows strips and prefixes reserved device names
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		if r < 32 {
+			return '_'
+		}
+		return r
+	}, name)
+
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+	stem, _, _ := strings.Cut(name, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		name = "_" + name
+	}
+	return name
+}
+
+// exportSink receives the files of an export. close finishes the export and abort
+// discards what a failed export has written where that is possible.
+type exportSink interface {
+	writeFile(name string, data []byte) error
+	close() error
+	abort()
+}
+
+// folderSink writes export files into a directory
+type folderSink struct {
+	dir string
+}
+
+func newFolderSink(dir string) (*folderSink, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	return &folderSink{dir: dir}, nil
+}
+
+func (s *folderSink) writeFile(name string, data []byte) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+func (s *folderSink) close() error {
+	return nil
+}
+
+// abort keeps the files already written; the folder may hold other files of the user
+func (s *folderSink) abort() {}
+
+// zipSink writes export files into a zip archive. The archive is written next to its
+// destination and renamed into place once complete, so a failed export leaves nothing.
+type zipSink struct {
+	path   string
+	file   *os.File
+	writer *zip.Writer
+}
+
+func newZipSink(path string) (*zipSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	file, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create zip file: %w", err)
+	}
+	return &zipSink{path: path, file: file, writer: zip.NewWriter(file)}, nil
+}
+
+func (s *zipSink) writeFile(name string, data []byte) error {
+	w, err := s.writer.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (s *zipSink) close() error {
+	if err := s.writer.Close(); err != nil {
+		s.abort()
+		return err
+	}
+	if err := s.file.Close(); err != nil {
+		os.Remove(s.file.Name())
+		return err
+	}
+	if err := os.Rename(s.file.Name(), s.path); err != nil {
+		os.Remove(s.file.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *zipSink) abort() {
+	s.writer.Close()
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
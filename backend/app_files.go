@@ -3,24 +3,29 @@ package backend
 import (
 	"encoding/base64"
 	"fmt"
-	"image"
 	_ "image/gif"
 	_ "image/jpeg"
-	"image/png"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
-	"bytes"
-
 	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // File Management Methods
 
+const (
+	// imageDownloadTimeout bounds a whole image download, including reading the body
+	imageDownloadTimeout = 60 * time.Second
+	// maxImageDownloadBytes is the largest image downloaded from a URL
+	maxImageDownloadBytes = 50 << 20
+)
+
+// imageDownloadClient is shared by every image download
+var imageDownloadClient = &http.Client{Timeout: imageDownloadTimeout}
+
 // GetUserDownloadDir returns the user's Download directory path
 func (a *App) GetUserDownloadDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -40,26 +45,16 @@ func (a *App) GetUserDownloadDir() (string, error) {
 }
 
 // ReadImageFile reads an image file from local path and returns base64 encoded data
+// Relative paths are resolved against the data root
 func (a *App) ReadImageFile(filePath string) (string, error) {
 	// Read file
-	data, err := os.ReadFile(filePath)
+	data, err := os.ReadFile(a.resolveImagePath(filePath))
 	if err != nil {
 		return "", fmt.Errorf("failed to read image file: %w", err)
 	}
 
 	// Determine content type from file extension
-	ext := strings.ToLower(filepath.Ext(filePath))
-	contentType := "image/png" // default
-	switch ext {
-	case ".jpg", ".jpeg":
-		contentType = "image/jpeg"
-	case ".webp":
-		contentType = "image/webp"
-	case ".gif":
-		contentType = "image/gif"
-	case ".png":
-		contentType = "image/png"
-	}
+	contentType := imageContentType(filePath)
 
 	// Encode to base64
 	base64Data := fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(data))
@@ -171,122 +166,81 @@ func (a *App) SelectReferenceImages(multiSelect bool) ([]string, error) {
 	return images, nil
 }
 
-// downloadImage helper to download and save an image to AppData/images
-func (a *App) downloadImage(url string) (string, error) {
-	resp, err := http.Get(url)
+// persistImage saves a remote URL or Data URI to the image store in the configured format
+// owner is recorded as a reference to the stored image; meta, when set, is embedded
+// as PNG text chunks
+// Returns the image reference relative to the data root
+func (a *App) persistImage(imageURL string, owner string, meta *ImageMetadata) (string, error) {
+	imageData, err := a.fetchImageData(imageURL)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to download: status %d", resp.StatusCode)
-	}
-
-	data, err := io.ReadAll(resp.Body)
+	info, err := a.storeConvertedImage(imageData, owner, meta)
 	if err != nil {
 		return "", err
 	}
+	return info.Path, nil
+}
 
-	// Determine extension
-	ext := ".png"
-	contentType := resp.Header.Get("Content-Type")
-	if strings.Contains(contentType, "jpeg") || strings.Contains(contentType, "jpg") {
-		ext = ".jpg"
+// fetchImageData returns the raw bytes behind a data URI, http(s) URL, image store ID,
+// history image ID or local path
+func (a *App) fetchImageData(src string) ([]byte, error) {
+	resolved, err := a.resolveReferenceImage(src)
+	if err != nil {
+		return nil, err
 	}
+	return resolved.data, nil
+}
 
-	filename := fmt.Sprintf("img_%d%s", time.Now().UnixNano(), ext)
-
-	// Get AppDir
-	configDir, _ := os.UserConfigDir() // Error handled in OnStartup usually, but re-check
-	appDir := filepath.Join(configDir, "SparkPrompt", "images")
-	if err := os.MkdirAll(appDir, 0755); err != nil {
-		return "", err
+// downloadImage fetches a remote image with imageDownloadClient, refusing bodies larger
+// than maxImageDownloadBytes, and returns its bytes and Content-Type
+func downloadImage(url string) ([]byte, string, error) {
+	resp, err := imageDownloadClient.Get(url)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download image: %w", err)
 	}
+	defer resp.Body.Close()
 
-	localPath := filepath.Join(appDir, filename)
-	if err := os.WriteFile(localPath, data, 0644); err != nil {
-		return "", err
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download image: status code %d", resp.StatusCode)
 	}
-
-	return localPath, nil
-}
-
-// persistImage saves a remote URL or Data URI to the local images directory, converting to PNG
-func (a *App) persistImage(imageURL string) (string, error) {
-	var imageData []byte
-
-	// 1. Get Raw Data
-	if strings.HasPrefix(imageURL, "data:") {
-		// Parse data URI
-		parts := strings.Split(imageURL, ",")
-		if len(parts) != 2 {
-			return "", fmt.Errorf("invalid data URI")
-		}
-		// Decode base64
-		decoded, err := base64.StdEncoding.DecodeString(parts[1])
-		if err != nil {
-			return "", fmt.Errorf("failed to decode base64 data: %w", err)
-		}
-		imageData = decoded
-	} else {
-		// Download image from URL
-		resp, err := http.Get(imageURL)
-		if err != nil {
-			return "", fmt.Errorf("failed to download image: %w", err)
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("failed to download image: status code %d", resp.StatusCode)
-		}
-
-		// Read image data
-		data, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return "", fmt.Errorf("failed to read image data: %w", err)
-		}
-		imageData = data
+	if resp.ContentLength > maxImageDownloadBytes {
+		return nil, "", fmt.Errorf("image is too large to download (%d bytes, limit %d)", resp.ContentLength, maxImageDownloadBytes)
 	}
 
-	// 2. Decode Image to check validity and prepare for PNG conversion
-	img, format, err := image.Decode(bytes.NewReader(imageData))
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageDownloadBytes+1))
 	if err != nil {
-		// Fallback: If we can't decode it (e.g. unknown format), should we fail?
-		// User requested "only store png".
-		return "", fmt.Errorf("failed to decode image (format: %s) for conversion to PNG: %w", format, err)
+		return nil, "", fmt.Errorf("failed to read image data: %w", err)
 	}
+	if len(data) > maxImageDownloadBytes {
+		return nil, "", fmt.Errorf("image is too large to download (limit %d bytes)", maxImageDownloadBytes)
+	}
+	return data, resp.Header.Get("Content-Type"), nil
+}
 
-	// 3. Generate filename and paths
-	// Always use .png extension
-	filename := fmt.Sprintf("ai_image_%d.png", time.Now().UnixNano())
+// storeConvertedImage converts image data to the configured storage format, embeds
+// meta and stores the result
+func (a *App) storeConvertedImage(imageData []byte, owner string, meta *ImageMetadata) (*StoredImage, error) {
+	return a.storeConvertedImageWith(imageData, owner, meta, a.storageSettings())
+}
 
-	// Get executable directory for images folder
-	execPath, err := os.Executable()
-	var appDataDir string
+// storeConvertedImageWith is storeConvertedImage with explicit storage settings
+func (a *App) storeConvertedImageWith(imageData []byte, owner string, meta *ImageMetadata, settings StorageSettings) (*StoredImage, error) {
+	data, encoding, err := encodeForStorage(imageData, settings, meta)
 	if err != nil {
-		return "", fmt.Errorf("failed to get user config directory: %w", err)
-	} else {
-		execDir := filepath.Dir(execPath)
-		appDataDir = filepath.Join(execDir, "images")
+		return nil, err
 	}
-
-	// Ensure images directory exists
-	if err := os.MkdirAll(appDataDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create images directory: %w", err)
+	if meta != nil && !encoding.Metadata {
+		format, _, _ := detectImageFormat(data)
+		fmt.Printf("Warning: %s\n", metadataDroppedMessage(format))
 	}
 
-	localPath := filepath.Join(appDataDir, filename)
-	file, err := os.Create(localPath)
+	// Store by content hash so identical images are only written once
+	info, err := a.storeEncodedImage(data, owner, encoding)
 	if err != nil {
-		return "", fmt.Errorf("failed to create image file: %w", err)
-	}
-	defer file.Close()
-
-	// 4. Encode as PNG
-	if err := png.Encode(file, img); err != nil {
-		return "", fmt.Errorf("failed to save as PNG: %w", err)
+		return nil, fmt.Errorf("failed to store image: %w", err)
 	}
 
-	return localPath, nil
+	return info, nil
 }
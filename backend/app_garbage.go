@@ -0,0 +1,198 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Image Garbage Collection Methods
+
+// CollectGarbage finds image files in the data root that are no longer referenced by
+// history records or template covers and deletes them. In dry-run mode nothing is
+// deleted and the report lists what would be removed, plus unreferenced files in the
+// image folders of older versions, which are never deleted since the store doesn't
+// own them. Store images that still have references in the index are kept even when
+// no record uses them yet, as an ingest writes the image before its record.
+func (a *App) CollectGarbage(dryRun bool) (*GarbageReport, error) {
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	// Collect every referenced file path along with its owners
+	owners := a.referencedImagePaths(history, templates)
+
+	// Hold the store lock from the scan through the index update so no image stored
+	// meanwhile is mistaken for an orphan
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	index, err := a.loadImageIndex()
+	if err != nil {
+		if errors.Is(err, errCorruptImageIndex) {
+			return nil, fmt.Errorf("%w, rebuild it before collecting garbage", errCorruptImageIndex)
+		}
+		return nil, err
+	}
+	live := make(map[string]bool, len(index))
+	for _, info := range index {
+		if len(info.Refs) > 0 {
+			live[filepath.Clean(a.resolveImagePath(info.Path))] = true
+		}
+	}
+
+	report := &GarbageReport{DryRun: dryRun, Orphans: []OrphanImage{}}
+
+	dirs := []string{a.imagesDir()}
+	if dryRun {
+		dirs = append(dirs, a.legacyImageDirs()...)
+	}
+
+	for _, dir := range dirs {
+		legacy := dir != a.imagesDir()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !isImageFileName(entry.Name()) {
+				continue
+			}
+			report.Scanned++
+
+			path := filepath.Join(dir, entry.Name())
+			if _, referenced := owners[filepath.Clean(path)]; referenced || live[filepath.Clean(path)] {
+				report.Referenced++
+				continue
+			}
+
+			var size int64
+			if info, err := entry.Info(); err == nil {
+				size = info.Size()
+			}
+
+			report.Orphans = append(report.Orphans, OrphanImage{Path: path, Size: size, Legacy: legacy})
+			if legacy {
+				continue
+			}
+			if dryRun {
+				report.ReclaimedBytes += size
+				continue
+			}
+
+			if err := os.Remove(path); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", path, err))
+				continue
+			}
+			a.removeThumbnails(path)
+			report.Deleted++
+			report.ReclaimedBytes += size
+		}
+	}
+
+	if !dryRun {
+		if err := a.syncImageIndexLocked(index, owners); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+
+	return report, nil
+}
+
+// Helper Methods
+
+// referencedImagePaths maps the file path of every local image that history records
+// and templates reference to the owners referencing it
+func (a *App) referencedImagePaths(history []HistoryRecord, templates []Template) map[string][]string {
+	owners := make(map[string][]string)
+	reference := func(ref string, owner string) {
+		if !isLocalImageRef(ref) {
+			return
+		}
+		path := filepath.Clean(a.resolveImagePath(ref))
+		owners[path] = addRef(owners[path], owner)
+	}
+
+	for _, record := range history {
+		for _, ref := range recordImageRefs(record) {
+			reference(ref, historyImageOwner(record.ID))
+		}
+	}
+	for _, t := range templates {
+		reference(t.ImageURL, templateImageOwner(t.ID))
+		for _, url := range t.ImageURLs {
+			reference(url, templateImageOwner(t.ID))
+		}
+	}
+	return owners
+}
+
+// syncImageIndex is syncImageIndexLocked for callers not holding imageMu
+func (a *App) syncImageIndex(owners map[string][]string) error {
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	index, err := a.loadImageIndex()
+	if err != nil {
+		return err
+	}
+	return a.syncImageIndexLocked(index, owners)
+}
+
+// syncImageIndexLocked adds the owners found in history and templates to image store
+// references and drops entries whose files no longer exist. References are never
+// removed, since owners come from a snapshot that may predate a running ingest.
+// The caller holds imageMu.
+func (a *App) syncImageIndexLocked(index imageIndex, owners map[string][]string) error {
+	for id, info := range index {
+		path := filepath.Clean(a.resolveImagePath(info.Path))
+		if _, err := os.Stat(path); err != nil {
+			delete(index, id)
+			continue
+		}
+		for _, owner := range owners[path] {
+			info.Refs = addRef(info.Refs, owner)
+		}
+	}
+
+	return a.saveImageIndex(index)
+}
+
+// legacyImageDirs returns the image folders written by older versions (next to the
+// executable and in the default app directory) that lie outside the current data root
+func (a *App) legacyImageDirs() []string {
+	var dirs []string
+	seen := map[string]bool{filepath.Clean(a.imagesDir()): true}
+
+	legacy := []string{filepath.Join(defaultAppDir(), imagesDirName)}
+	if execDir, err := executableDir(); err == nil {
+		legacy = append(legacy, filepath.Join(execDir, imagesDirName))
+	}
+
+	for _, dir := range legacy {
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// isImageFileName reports whether name has an image file extension
+func isImageFileName(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".webp":
+		return true
+	}
+	return false
+}
@@ -7,11 +7,15 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"strings"
 	"time"
 )
 
+// maxSeed is the largest seed accepted by the providers
+const maxSeed = 2147483647
+
 // AI Image Generation Methods
 
 // GenerateImage generates images using the specified AI provider
@@ -23,22 +27,210 @@ func (a *App) GenerateImage(req *GenerateRequest) (*GenerateResponse, error) {
 
 	provider, exists := config.Providers[req.Provider]
 	if !exists {
-		return a.newErrorResponse("PROVIDER_NOT_FOUND", fmt.Sprintf("Provider %s not configured", req.Provider), req.Provider), nil
+		return a.rejectGeneration(req, req.Model, a.newErrorResponse("PROVIDER_NOT_FOUND", fmt.Sprintf("Provider %s not configured", req.Provider), req.Provider)), nil
 	}
 
 	// Check if API key is configured
 	if provider.APIKey == "" {
-		return a.newErrorResponse("MISSING_API_KEY", "API key is required for this provider", req.Provider), nil
+		return a.rejectGeneration(req, req.Model, a.newErrorResponse("MISSING_API_KEY", "API key is required for this provider", req.Provider)), nil
+	}
+
+	model := req.Model
+	if model == "" {
+		model = provider.DefaultModel
+	}
+
+	// Edit models fail at the provider without an input image
+	if provider.ModelCapabilities[model].RequiresReferenceImage && len(req.Images) == 0 {
+		return a.rejectGeneration(req, model, a.newErrorResponse("REFERENCE_IMAGE_REQUIRED", fmt.Sprintf("Model %s edits an image and needs a reference image", model), req.Provider)), nil
+	}
+
+	// Refuse when a spending budget covering this provider can't pay for the request
+	hold, apiError := a.reserveBudget(&config.Usage, req.Provider, model, requestedImageCount(req))
+	if apiError != nil {
+		return a.rejectGeneration(req, model, &GenerateResponse{Success: false, Error: apiError}), nil
+	}
+	defer a.releaseBudget(hold)
+
+	// Pick a seed so the generation can be replayed exactly from history
+	if req.Seed == nil {
+		seed := rand.Int63n(maxSeed)
+		req.Seed = &seed
+	}
+
+	// Fail before generating when the chosen post-processing pipeline is missing or invalid
+	if req.PostProcess == nil && req.Pipeline != "" {
+		pipeline, exists := config.PostProcessing[req.Pipeline]
+		if !exists {
+			return a.rejectGeneration(req, model, a.newErrorResponse("PIPELINE_NOT_FOUND", fmt.Sprintf("Post-processing pipeline %s not found", req.Pipeline), req.Provider)), nil
+		}
+		pipeline.Steps = append([]PostProcessStep(nil), pipeline.Steps...)
+		req.PostProcess = &pipeline
+	}
+	if req.PostProcess != nil {
+		if err := validatePipeline(req.PostProcess); err != nil {
+			return a.rejectGeneration(req, model, a.newErrorResponse("INVALID_PIPELINE", err.Error(), req.Provider)), nil
+		}
 	}
 
 	// Call the appropriate provider based on provider ID
+	var resp *GenerateResponse
+	start := time.Now()
 	switch req.Provider {
 	case "dashscope":
-		return a.generateImageDashScope(&provider, req)
+		resp, err = a.generateImageDashScope(&provider, req)
 	case "nanobanana":
-		return a.generateImageNanobanana(&provider, req)
+		resp, err = a.generateImageNanobanana(&provider, req)
 	default:
-		return a.newErrorResponse("UNSUPPORTED_PROVIDER", fmt.Sprintf("Provider %s is not yet supported", req.Provider), req.Provider), nil
+		return a.rejectGeneration(req, model, a.newErrorResponse("UNSUPPORTED_PROVIDER", fmt.Sprintf("Provider %s is not yet supported", req.Provider), req.Provider)), nil
+	}
+	a.recordGenerationAttempt(req, model, resp, err, time.Since(start))
+
+	if resp != nil {
+		resp.Seed = req.Seed
+		if resp.Success {
+			a.recordUsage(&config.Usage, req.Provider, model, resp, hold)
+		}
+		if resp.Success && req.PostProcess != nil {
+			if err := a.postProcessResponse(resp, req.PostProcess); err != nil {
+				resp.Success = false
+				resp.Images = nil
+				resp.Error = &APIError{Code: "POST_PROCESS_FAILED", Message: err.Error(), Provider: req.Provider}
+			}
+		}
+	}
+	return resp, err
+}
+
+// RegenerateFromHistory replays the generation behind a history record, optionally with
+// changed parameters, and saves the result as a new history record
+func (a *App) RegenerateFromHistory(recordID string, overrides RegenerateOverrides) (*RegenerateResult, error) {
+	source, exists, err := a.historyRecord(recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("history record not found: %s", recordID)
+	}
+
+	req := a.requestFromParams(source.Params)
+	applyRegenerateOverrides(&req, overrides)
+
+	resp, err := a.GenerateImage(&req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RegenerateResult{Response: resp}
+	if !resp.Success {
+		return result, nil
+	}
+
+	record, err := a.saveGeneration(&req, resp.Images, recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save regenerated images: %w", err)
+	}
+	result.Record = record
+
+	return result, nil
+}
+
+// requestFromParams rebuilds a generation request from recorded parameters; reference
+// images stay image store references and are loaded when the request is sent
+func (a *App) requestFromParams(params GenerationParams) GenerateRequest {
+	req := GenerateRequest{
+		Prompt:           params.Prompt,
+		Provider:         params.Provider,
+		Model:            params.Model,
+		Size:             params.Size,
+		Parameters:       params.Parameters,
+		NegativePrompt:   params.NegativePrompt,
+		Seed:             params.Seed,
+		TemplateID:       params.TemplateID,
+		TemplateRevision: params.TemplateRevision,
+		Language:         params.Language,
+		Selections:       params.Selections,
+		Images:           append([]string(nil), params.ReferenceImages...),
+	}
+	if params.PostProcess != nil {
+		// Replay the steps as recorded, even if the saved pipeline changed since
+		pipeline := *params.PostProcess
+		pipeline.Steps = append([]PostProcessStep(nil), pipeline.Steps...)
+		req.Pipeline = pipeline.Name
+		req.PostProcess = &pipeline
+	}
+
+	return req
+}
+
+// applyRegenerateOverrides replaces request fields with the non-empty overrides
+func applyRegenerateOverrides(req *GenerateRequest, overrides RegenerateOverrides) {
+	if overrides.Prompt != "" {
+		req.Prompt = overrides.Prompt
+	}
+	if overrides.Provider != "" {
+		req.Provider = overrides.Provider
+	}
+	if overrides.Model != "" {
+		req.Model = overrides.Model
+	}
+	if overrides.Size != "" {
+		req.Size = overrides.Size
+	}
+	if overrides.NegativePrompt != nil {
+		req.NegativePrompt = *overrides.NegativePrompt
+	}
+	if overrides.Seed != nil {
+		req.Seed = overrides.Seed
+	} else if overrides.RandomSeed {
+		req.Seed = nil
+	}
+	if overrides.Parameters != nil {
+		req.Parameters = overrides.Parameters
+	}
+}
+
+// requestedImageCount returns how many images a request asks for, used to estimate its
+// cost before it is sent
+func requestedImageCount(req *GenerateRequest) int {
+	switch n := req.Parameters["n"].(type) {
+	case float64:
+		if n >= 1 {
+			return int(n)
+		}
+	case int:
+		if n >= 1 {
+			return n
+		}
+	}
+	return 1
+}
+
+// applyGenerationControls injects the seed and negative prompt into a provider request body
+func (a *App) applyGenerationControls(providerID string, body map[string]interface{}, req *GenerateRequest) {
+	switch providerID {
+	case "dashscope":
+		params, ok := body["parameters"].(map[string]interface{})
+		if !ok {
+			params = make(map[string]interface{})
+			body["parameters"] = params
+		}
+		if req.Seed != nil {
+			params["seed"] = *req.Seed
+		}
+		if req.NegativePrompt != "" {
+			params["negative_prompt"] = req.NegativePrompt
+		}
+	case "nanobanana":
+		// Gemini has no negative prompt; only the seed can be passed through
+		config, ok := body["generationConfig"].(map[string]interface{})
+		if !ok {
+			config = make(map[string]interface{})
+			body["generationConfig"] = config
+		}
+		if req.Seed != nil {
+			config["seed"] = *req.Seed
+		}
 	}
 }
 
@@ -60,11 +252,18 @@ func (a *App) generateImageDashScope(provider *ProviderConfig, req *GenerateRequ
 		}
 	}
 
+	// Check and preprocess reference images for image editing models
+	referenceImages, apiError := a.referenceImagesFor(provider, model, req.Images)
+	if apiError != nil {
+		return &GenerateResponse{Success: false, Error: apiError}, nil
+	}
+
 	// Build request payload using template
-	requestData, err := a.buildRequestFromTemplate(provider, req, model, size)
+	requestData, err := a.buildRequestFromTemplate(provider, req, model, size, referenceImages)
 	if err != nil {
 		return a.newErrorResponse("TEMPLATE_ERROR", fmt.Sprintf("Failed to build request: %v", err), req.Provider), nil
 	}
+	a.applyGenerationControls(req.Provider, requestData, req)
 
 	// Make HTTP request
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
@@ -117,18 +316,32 @@ func (a *App) generateImageDashScope(provider *ProviderConfig, req *GenerateRequ
 }
 
 // buildRequestFromTemplate builds the API request using the configured template
-func (a *App) buildRequestFromTemplate(provider *ProviderConfig, req *GenerateRequest, model, size string) (map[string]interface{}, error) {
+// Templates can place the reference images and prompt as message content with "{{.ContentParts}}"
+func (a *App) buildRequestFromTemplate(provider *ProviderConfig, req *GenerateRequest, model, size string, referenceImages []string) (map[string]interface{}, error) {
 	// Get model-specific request template
 	requestTemplate := a.getRequestTemplate(provider, model)
 	if requestTemplate == nil {
 		return nil, fmt.Errorf("no request template found for model %s", model)
 	}
 
+	// Construct ContentParts
+	// Structure: [{"image": "data:image/png;base64,..."}, ..., {"text": prompt}]
+	contentParts := make([]map[string]interface{}, 0, len(referenceImages)+1)
+	for _, dataURI := range referenceImages {
+		contentParts = append(contentParts, map[string]interface{}{
+			"image": dataURI,
+		})
+	}
+	contentParts = append(contentParts, map[string]interface{}{
+		"text": req.Prompt,
+	})
+
 	// Prepare template variables
 	templateVars := map[string]interface{}{
-		"Model":  model,
-		"Prompt": req.Prompt,
-		"Size":   size,
+		"Model":        model,
+		"Prompt":       req.Prompt,
+		"Size":         size,
+		"ContentParts": contentParts,
 	}
 
 	// Process the request template
@@ -223,8 +436,11 @@ func (a *App) parseDashScopeResponse(body []byte) (*GenerateResponse, error) {
 			} `json:"choices"`
 		} `json:"output"`
 		Usage struct {
-			Width  int `json:"width"`
-			Height int `json:"height"`
+			Width        int `json:"width"`
+			Height       int `json:"height"`
+			ImageCount   int `json:"image_count"`
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
 		} `json:"usage"`
 		RequestID string `json:"request_id"`
 	}
@@ -254,7 +470,17 @@ func (a *App) parseDashScopeResponse(body []byte) (*GenerateResponse, error) {
 		return a.newErrorResponse("NO_IMAGES_GENERATED", "No images were generated in the response", "dashscope"), nil
 	}
 
-	return &GenerateResponse{Success: true, Images: images}, nil
+	usage := &GenerationUsage{
+		Images:       dashScopeResp.Usage.ImageCount,
+		InputTokens:  dashScopeResp.Usage.InputTokens,
+		OutputTokens: dashScopeResp.Usage.OutputTokens,
+		RequestID:    dashScopeResp.RequestID,
+	}
+	if usage.Images == 0 {
+		usage.Images = len(images)
+	}
+
+	return &GenerateResponse{Success: true, Images: images, Usage: usage}, nil
 }
 
 // newErrorResponse creates a new error response
@@ -311,19 +537,10 @@ func (a *App) generateImageNanobanana(provider *ProviderConfig, req *GenerateReq
 		}
 	}
 
-	// Limit reference images based on model capabilities
-	var referenceImages []string
-	maxImages := 0
-	if caps, exists := provider.ModelCapabilities[model]; exists {
-		maxImages = caps.MaxReferenceImages
-	}
-
-	if len(req.Images) > 0 && maxImages > 0 {
-		count := len(req.Images)
-		if count > maxImages {
-			count = maxImages
-		}
-		referenceImages = req.Images[:count]
+	// Check and preprocess reference images based on model capabilities
+	referenceImages, apiError := a.referenceImagesFor(provider, model, req.Images)
+	if apiError != nil {
+		return &GenerateResponse{Success: false, Error: apiError}, nil
 	}
 
 	// Construct ContentParts
@@ -379,6 +596,7 @@ func (a *App) generateImageNanobanana(provider *ProviderConfig, req *GenerateReq
 	if !ok {
 		return nil, fmt.Errorf("template processing did not result in a map")
 	}
+	a.applyGenerationControls(req.Provider, requestBody, req)
 
 	// Make HTTP request
 	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second) // Longer timeout for images
@@ -472,6 +690,10 @@ func (a *App) parseNanobananaResponse(body []byte) (*GenerateResponse, error) {
 				} `json:"parts"`
 			} `json:"content"`
 		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
 	}
 
 	if err := json.Unmarshal(body, &geminiResp); err != nil {
@@ -517,5 +739,11 @@ func (a *App) parseNanobananaResponse(body []byte) (*GenerateResponse, error) {
 		return a.newErrorResponse("NO_IMAGES_FOUND", msg, "nanobanana"), nil
 	}
 
-	return &GenerateResponse{Success: true, Images: images}, nil
+	usage := &GenerationUsage{
+		Images:       len(images),
+		InputTokens:  geminiResp.UsageMetadata.PromptTokenCount,
+		OutputTokens: geminiResp.UsageMetadata.CandidatesTokenCount,
+	}
+
+	return &GenerateResponse{Success: true, Images: images, Usage: usage}, nil
 }
@@ -0,0 +1,361 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Contact Sheet Methods
+//
+// ComposeGrid lays out a set of images as tiles on one sheet, optionally captioned,
+// and saves the sheet as a new history record linked to the records of its tiles.
+
+const (
+	defaultGridTileSize = 512
+	defaultGridPadding  = 16
+	// maxGridImages bounds the number of tiles on one sheet
+	maxGridImages = 100
+	// maxGridTileSize bounds the tile size so sheets stay a reasonable size
+	maxGridTileSize = 2048
+	// maxGridSheetPixels bounds the whole sheet (width * height), about 256 MB in memory
+	maxGridSheetPixels = 64 << 20
+
+	gridCaptionNone       = "none"
+	gridCaptionModel      = "model"
+	gridCaptionSelections = "selections"
+	gridCaptionPrompt     = "prompt"
+	gridCaptionSeed       = "seed"
+)
+
+// ComposeGrid draws the images as a grid and stores the result as a history record.
+// Images may be given as anything a reference image accepts, including image store
+// references and history image IDs.
+//
+// Captions are drawn with a built-in ASCII font. Explicit captions with other
+// characters are refused; prompt and selections captions, usually Chinese in this
+// app, fall back to the model and seed (or the template ID) of their record.
+func (a *App) ComposeGrid(imageRefs []string, options GridOptions) (*HistoryRecord, error) {
+	if len(imageRefs) == 0 {
+		return nil, fmt.Errorf("no images to compose")
+	}
+	if len(imageRefs) > maxGridImages {
+		return nil, fmt.Errorf("too many images: %d (maximum %d)", len(imageRefs), maxGridImages)
+	}
+	if len(options.Captions) > 0 && len(options.Captions) != len(imageRefs) {
+		return nil, fmt.Errorf("got %d captions for %d images", len(options.Captions), len(imageRefs))
+	}
+	if err := normalizeGridOptions(&options, len(imageRefs)); err != nil {
+		return nil, err
+	}
+	for i, caption := range options.Captions {
+		if !drawableText(caption) {
+			return nil, fmt.Errorf("caption %d has characters the built-in ASCII font can't draw", i+1)
+		}
+	}
+
+	background, err := parseColor(options.Background, color.White)
+	if err != nil {
+		return nil, err
+	}
+	captionColor, err := parseColor(options.CaptionColor, color.Black)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	owners := imageOwners(history)
+
+	// Load every tile first so one bad image fails the sheet before any drawing. Large
+	// images are scaled down to the cell as they load so only tile-sized copies are kept.
+	tiles := make([]image.Image, len(imageRefs))
+	records := make([]*HistoryRecord, len(imageRefs))
+	var failures []string
+	for i, ref := range imageRefs {
+		resolved, err := a.resolveReferenceImage(ref)
+		if err == nil {
+			tiles[i], _, err = image.Decode(bytes.NewReader(resolved.data))
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("image %d (%s): %v", i+1, describeImageSource(ref), err))
+			continue
+		}
+		b := tiles[i].Bounds()
+		if w, h := fitWithin(b.Dx(), b.Dy(), options.TileSize); w < b.Dx() || h < b.Dy() {
+			tiles[i] = resampleImage(tiles[i], w, h)
+		}
+
+		if record, ok := owners[ref]; ok {
+			records[i] = record
+		} else if record, ok := owners[resolved.storeRef]; ok && resolved.storeRef != "" {
+			records[i] = record
+		}
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("failed to load images: %s", strings.Join(failures, "; "))
+	}
+
+	captions := options.Captions
+	if len(captions) == 0 && options.Caption != gridCaptionNone {
+		captions = make([]string, len(imageRefs))
+		var fallbacks []string
+		for i, record := range records {
+			captions[i] = gridCaption(record, options.Caption)
+			if !drawableText(captions[i]) {
+				// Prompts and option values are often Chinese, which the font can't draw
+				captions[i] = gridFallbackCaption(record)
+				fallbacks = append(fallbacks, fmt.Sprint(i+1))
+			}
+		}
+		if len(fallbacks) > 0 {
+			fmt.Printf("Warning: The %s captions of images %s can't be drawn with the built-in ASCII font; using model, seed or template instead\n", options.Caption, strings.Join(fallbacks, ", "))
+		}
+	}
+
+	sheet := drawGrid(tiles, captions, options, background, captionColor)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, sheet); err != nil {
+		return nil, fmt.Errorf("failed to encode contact sheet: %w", err)
+	}
+
+	// Link the sheet to each distinct source record, in tile order
+	var sources []string
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if record != nil && !seen[record.ID] {
+			seen[record.ID] = true
+			sources = append(sources, record.ID)
+		}
+	}
+
+	described := make([]string, len(imageRefs))
+	for i, ref := range imageRefs {
+		described[i] = describeImageSource(ref)
+	}
+
+	title := options.Title
+	if title == "" {
+		title = fmt.Sprintf("Contact sheet of %d images", len(imageRefs))
+	}
+
+	b := sheet.Bounds()
+	result, err := a.ingestRecord(HistoryRecord{
+		Params: GenerationParams{
+			Prompt: title,
+			Size:   fmt.Sprintf("%d*%d", b.Dx(), b.Dy()),
+		},
+		Images:        []GeneratedImage{{URL: imageDataURI("image/png", buf.Bytes()), Width: b.Dx(), Height: b.Dy()}},
+		SourceRecords: sources,
+		Metadata: map[string]interface{}{
+			"contactSheet": map[string]interface{}{
+				"images":  described,
+				"columns": options.Columns,
+				"caption": options.Caption,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Record, nil
+}
+
+// Helper Methods
+
+// normalizeGridOptions validates options and fills in defaults for count images
+func normalizeGridOptions(options *GridOptions, count int) error {
+	if options.Columns < 0 || options.TileSize < 0 || options.Padding < 0 {
+		return fmt.Errorf("columns, tile size and padding cannot be negative")
+	}
+	if options.TileSize > maxGridTileSize {
+		return fmt.Errorf("tile size cannot exceed %d", maxGridTileSize)
+	}
+
+	if options.Columns == 0 {
+		options.Columns = int(math.Ceil(math.Sqrt(float64(count))))
+	}
+	if options.Columns > count {
+		options.Columns = count
+	}
+	if options.TileSize == 0 {
+		options.TileSize = defaultGridTileSize
+	}
+	if options.Padding == 0 {
+		options.Padding = defaultGridPadding
+	}
+
+	switch options.Caption {
+	case "":
+		options.Caption = gridCaptionNone
+	case gridCaptionNone, gridCaptionModel, gridCaptionSelections, gridCaptionPrompt, gridCaptionSeed:
+	default:
+		return fmt.Errorf("unknown caption: %s", options.Caption)
+	}
+
+	captioned := len(options.Captions) > 0 || options.Caption != gridCaptionNone
+	width, height, _ := gridSheetSize(*options, count, captioned)
+	if int64(width)*int64(height) > maxGridSheetPixels {
+		return fmt.Errorf("contact sheet of %dx%d pixels is too large; use fewer images or a smaller tile size", width, height)
+	}
+	return nil
+}
+
+// gridSheetSize returns the sheet width and height for count tiles and the height
+// of the caption line under each tile
+func gridSheetSize(options GridOptions, count int, captioned bool) (int, int, int) {
+	cell := options.TileSize
+	pad := options.Padding
+	columns := options.Columns
+	rows := (count + columns - 1) / columns
+
+	// Captions get one line of the bitmap font, scaled with the tile size
+	captionHeight := 0
+	if captioned {
+		_, textHeight := measureText("M", gridTextScale(cell))
+		captionHeight = textHeight + pad/2
+	}
+
+	width := columns*cell + (columns+1)*pad
+	height := rows*(cell+captionHeight) + (rows+1)*pad
+	return width, height, captionHeight
+}
+
+// gridTextScale is the caption font scale for a tile size
+func gridTextScale(cell int) int {
+	return max(cell/256, 1)
+}
+
+// drawGrid lays tiles out left to right, top to bottom, each scaled to fit a square
+// cell and centered in it, with its caption below
+func drawGrid(tiles []image.Image, captions []string, options GridOptions, background, captionColor color.Color) *image.RGBA {
+	cell := options.TileSize
+	pad := options.Padding
+	columns := options.Columns
+	textScale := gridTextScale(cell)
+
+	width, height, captionHeight := gridSheetSize(options, len(tiles), len(captions) > 0)
+	sheet := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	for i, tile := range tiles {
+		x := pad + (i%columns)*(cell+pad)
+		y := pad + (i/columns)*(cell+captionHeight+pad)
+
+		b := tile.Bounds()
+		w, h := fitWithin(b.Dx(), b.Dy(), cell)
+		if w < cell && h < cell {
+			// Small images are scaled up until one side fills the cell
+			scale := float64(cell) / float64(max(b.Dx(), b.Dy()))
+			w = max(int(math.Round(float64(b.Dx())*scale)), 1)
+			h = max(int(math.Round(float64(b.Dy())*scale)), 1)
+		}
+		filter := triangleFilter
+		if w > b.Dx() {
+			filter = lanczosFilter
+		}
+		scaled := resampleImageWith(tile, w, h, filter)
+
+		offset := image.Pt(x+(cell-w)/2, y+(cell-h)/2)
+		draw.Draw(sheet, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Over)
+
+		if i < len(captions) && captions[i] != "" {
+			text := fitCaption(captions[i], cell, textScale)
+			label := renderText(text, textScale, captionColor)
+			lw := label.Bounds().Dx()
+			at := image.Pt(x+(cell-lw)/2, y+cell+pad/2)
+			draw.Draw(sheet, label.Bounds().Add(at), label, image.Point{}, draw.Over)
+		}
+	}
+	return sheet
+}
+
+// fitCaption shortens text with an ellipsis so it fits width at the given font scale
+func fitCaption(text string, width, scale int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if w, _ := measureText(text, scale); w <= width {
+		return text
+	}
+
+	for n := len(runes) - 1; n > 0; n-- {
+		candidate := string(runes[:n]) + "..."
+		if w, _ := measureText(candidate, scale); w <= width {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// gridCaption returns the caption of a tile from its source record
+func gridCaption(record *HistoryRecord, kind string) string {
+	if record == nil {
+		return ""
+	}
+
+	params := record.Params
+	switch kind {
+	case gridCaptionModel:
+		return params.Model
+	case gridCaptionPrompt:
+		return params.Prompt
+	case gridCaptionSeed:
+		if params.Seed != nil {
+			return fmt.Sprintf("seed %d", *params.Seed)
+		}
+	case gridCaptionSelections:
+		keys := make([]string, 0, len(params.Selections))
+		for key := range params.Selections {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		values := make([]string, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, params.Selections[key])
+		}
+		return strings.Join(values, " / ")
+	}
+	return ""
+}
+
+// gridFallbackCaption returns the model and seed of record, or its template ID when
+// neither can be drawn, for captions the built-in font can't draw
+func gridFallbackCaption(record *HistoryRecord) string {
+	var parts []string
+	for _, kind := range []string{gridCaptionModel, gridCaptionSeed} {
+		if caption := gridCaption(record, kind); caption != "" && drawableText(caption) {
+			parts = append(parts, caption)
+		}
+	}
+	if len(parts) == 0 && record != nil && drawableText(record.Params.TemplateID) {
+		return record.Params.TemplateID
+	}
+	return strings.Join(parts, ", ")
+}
+
+// imageOwners maps every history image ID and URL to the record holding it
+func imageOwners(history []HistoryRecord) map[string]*HistoryRecord {
+	owners := make(map[string]*HistoryRecord)
+	for i := range history {
+		record := &history[i]
+		for _, img := range record.Images {
+			if img.ID != "" {
+				owners[img.ID] = record
+			}
+			if img.URL != "" {
+				owners[img.URL] = record
+			}
+		}
+	}
+	return owners
+}
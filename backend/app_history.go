@@ -1,138 +1,95 @@
 package backend
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
-	"time"
 )
 
 // AI History Management Methods
 
-// SaveAIHistory saves AI generation history to file system
+// SaveAIHistory saves AI generation history to file system. Stored images gained or
+// dropped by the save are referenced or released.
 func (a *App) SaveAIHistory(history []HistoryRecord) error {
-	data, err := json.MarshalIndent(history, "", "  ")
+	previous, err := a.LoadAIHistory()
 	if err != nil {
-		return fmt.Errorf("failed to marshal history: %w", err)
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+	if err := a.writeAIHistory(history); err != nil {
+		return err
 	}
+	a.updateImageRefs(historyImageRefs(previous), historyImageRefs(history))
 
-	return os.WriteFile(a.historyPath, data, 0644)
+	docs := make([]*searchDoc, 0, len(history))
+	for _, record := range history {
+		docs = append(docs, historySearchDoc(record))
+	}
+	a.search.replaceKind(searchKindHistory, docs)
+	return nil
+}
+
+// writeAIHistory rewrites the history log without touching the search index
+func (a *App) writeAIHistory(history []HistoryRecord) error {
+	return a.rewriteHistoryLog(history)
 }
 
 // LoadAIHistory loads AI generation history from file system
 func (a *App) LoadAIHistory() ([]HistoryRecord, error) {
-	data, err := os.ReadFile(a.historyPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return []HistoryRecord{}, nil // Return empty array if file doesn't exist
-		}
-		return nil, fmt.Errorf("failed to read history file: %w", err)
-	}
-
-	var history []HistoryRecord
-	if err := json.Unmarshal(data, &history); err != nil {
-		// Return empty array if parsing fails to avoid breaking the app
-		return []HistoryRecord{}, nil
-	}
-
-	return history, nil
+	return a.readHistoryLog()
 }
 
 // DeleteAIHistoryRecord deletes a specific record from AI history
 func (a *App) DeleteAIHistoryRecord(recordId string) error {
-	history, err := a.LoadAIHistory()
+	record, exists, err := a.historyRecord(recordId)
 	if err != nil {
 		return fmt.Errorf("failed to load history: %w", err)
 	}
+	if !exists {
+		return nil
+	}
 
-	// Find the record to delete and remove associated image files
-	var recordToDelete *HistoryRecord
-	for _, record := range history {
-		if record.ID == recordId {
-			recordToDelete = &record
-			break
-		}
+	// Append a tombstone for the specified record
+	if err := a.appendHistoryLog(historyLogEntry{Op: historyOpDelete, ID: recordId}); err != nil {
+		return err
 	}
+	a.search.remove(searchKindHistory, recordId)
+
+	// Release image files associated with this record once it is gone from history;
+	// shared images are kept until no other record or template references them
+	a.releaseHistoryImages(record)
+	return nil
+}
 
-	// Delete image files associated with this record
-	if recordToDelete != nil {
-		for _, img := range recordToDelete.Images {
-			if img.URL != "" {
-				// Check if it's a local file path
-				if !strings.HasPrefix(img.URL, "http://") && !strings.HasPrefix(img.URL, "https://") {
-					// Try to delete the file
-					if err := os.Remove(img.URL); err != nil {
-						// Log error but don't fail the operation
-						fmt.Printf("Warning: Failed to delete image file %s: %v\n", img.URL, err)
-					}
-				}
-			}
+// releaseHistoryImages releases the generated and reference images owned by a record
+func (a *App) releaseHistoryImages(record HistoryRecord) {
+	owner := historyImageOwner(record.ID)
+	for _, img := range record.Images {
+		if img.URL == "" || !isLocalImageRef(img.URL) {
+			continue
+		}
+		if err := a.releaseImage(img.URL, owner); err != nil {
+			// Log error but don't fail the operation
+			fmt.Printf("Warning: Failed to delete image file %s: %v\n", img.URL, err)
 		}
 	}
-
-	// Filter out the specified record
-	filtered := make([]HistoryRecord, 0, len(history))
-	for _, record := range history {
-		if record.ID != recordId {
-			filtered = append(filtered, record)
+	for _, ref := range record.Params.ReferenceImages {
+		if imageIDFromRef(ref) == "" {
+			continue
+		}
+		if err := a.releaseImage(ref, owner); err != nil {
+			fmt.Printf("Warning: Failed to release reference image %s: %v\n", ref, err)
 		}
 	}
-
-	return a.SaveAIHistory(filtered)
 }
 
 // AddHistoryRecord saves a history record and ensures images are saved locally
 func (a *App) AddHistoryRecord(record HistoryRecord) error {
-	// Ensure all images are saved locally
-	for i, img := range record.Images {
-		if strings.HasPrefix(img.URL, "http://") || strings.HasPrefix(img.URL, "https://") {
-			// Download and save locally
-			localPath, err := a.downloadImage(img.URL)
-			if err != nil {
-				fmt.Printf("Warning: Failed to download image %s: %v\n", img.URL, err)
-				continue
-			}
-			record.Images[i].URL = localPath
-		}
-	}
-
-	// Check if record exists (update) or new
-	history, err := a.LoadAIHistory()
-	if err != nil {
-		history = []HistoryRecord{}
-	}
-
-	// Add to history (prepend for consistency usually, or append?)
-	// Let's prepend so newest is first implies UI sorting, but appending is safer for file structure.
-	// UI can handle sorting.
-	history = append(history, record)
-
-	return a.SaveAIHistory(history)
+	_, err := a.ingestRecord(record)
+	return err
 }
 
-// DownloadImageAndSaveHistory downloads an image from URL to local data folder and saves to history
-// Returns the local file path
 // DownloadImageAndSaveHistory downloads an image from URL (or handles data URI) to local data folder and saves to history
-// Returns the local file path
-// post-refactor: use persistImage helper
+// Returns the image reference relative to the data root
 func (a *App) DownloadImageAndSaveHistory(imageURL string, prompt string, provider string, model string, size string, parameters map[string]interface{}) (string, error) {
-	localPath, err := a.persistImage(imageURL)
-	if err != nil {
-		return "", err
-	}
-
-	// Generate filename based on timestamp (persistImage creates a name, but for history we might want just ID linking?
-	// persistImage returns full path. We can use that.)
-
-	// Create history record
-	// Extract filename from localPath just for unique ID if needed
-	filename := filepath.Base(localPath)
-	recordID := fmt.Sprintf("record_%s", filename)
-
-	record := HistoryRecord{
-		ID: recordID,
+	result, err := a.ingestRecord(HistoryRecord{
 		Params: GenerationParams{
 			Prompt:     prompt,
 			Provider:   provider,
@@ -140,30 +97,79 @@ func (a *App) DownloadImageAndSaveHistory(imageURL string, prompt string, provid
 			Size:       size,
 			Parameters: parameters,
 		},
-		Images: []GeneratedImage{
-			{
-				ID:  recordID,
-				URL: localPath,
-			},
-		},
-		Timestamp: time.Now().Unix(),
-		Metadata:  make(map[string]interface{}),
+		Images: []GeneratedImage{{URL: imageURL}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return result.Record.Images[0].URL, nil
+}
+
+// SaveGenerationToHistory stores generated images together with the full request
+// provenance (template, language, selections, seed, reference images) as one history record
+func (a *App) SaveGenerationToHistory(req GenerateRequest, images []GeneratedImage) (*HistoryRecord, error) {
+	return a.saveGeneration(&req, images, "")
+}
+
+// saveGeneration stores the images of a generation as one history record.
+// regeneratedFrom links the record to the record it was replayed from.
+func (a *App) saveGeneration(req *GenerateRequest, images []GeneratedImage, regeneratedFrom string) (*HistoryRecord, error) {
+	// Provider image IDs are replaced by record-scoped IDs
+	sources := make([]GeneratedImage, 0, len(images))
+	for _, img := range images {
+		sources = append(sources, GeneratedImage{URL: img.URL, Width: img.Width, Height: img.Height})
 	}
 
-	// Load existing history
-	history, err := a.LoadAIHistory()
+	// Record the pipeline as it was applied, since the saved one may change later
+	pipeline := req.PostProcess
+	if pipeline == nil && req.Pipeline != "" {
+		var err error
+		if pipeline, err = a.postProcessPipeline(req.Pipeline); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := a.ingestRecord(HistoryRecord{
+		Params: GenerationParams{
+			Prompt:           req.Prompt,
+			Provider:         req.Provider,
+			Model:            req.Model,
+			Size:             req.Size,
+			Parameters:       req.Parameters,
+			TemplateID:       req.TemplateID,
+			TemplateRevision: req.TemplateRevision,
+			Language:         req.Language,
+			Selections:       req.Selections,
+			Seed:             req.Seed,
+			NegativePrompt:   req.NegativePrompt,
+			ReferenceImages:  req.Images,
+			RegeneratedFrom:  regeneratedFrom,
+			PostProcess:      pipeline,
+		},
+		Images: sources,
+	})
 	if err != nil {
-		history = []HistoryRecord{}
+		return nil, err
 	}
 
-	// Add new record
-	history = append(history, record)
+	return result.Record, nil
+}
 
-	// Save history
-	if err := a.SaveAIHistory(history); err != nil {
-		// If history save fails, we still have the image saved, so log error but don't fail
-		fmt.Printf("Warning: Failed to save history record: %v\n", err)
+// storeReferenceImage puts a reference image (data URI, URL, local path or image ID)
+// into the image store, keeping its original bytes, and returns its reference
+func (a *App) storeReferenceImage(src string, owner string) (string, error) {
+	resolved, err := a.resolveReferenceImage(src)
+	if err != nil {
+		return "", err
+	}
+	if resolved.storeRef != "" {
+		return resolved.storeRef, a.addImageRef(resolved.storeRef, owner)
 	}
 
-	return localPath, nil
+	info, err := a.storeImage(resolved.data, owner)
+	if err != nil {
+		return "", err
+	}
+	return info.Path, nil
 }
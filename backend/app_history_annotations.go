@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// History Annotation Methods
+//
+// Annotations are kept in the record metadata under "annotations" and indexed by the
+// history cache, so filtering by them doesn't scan every record.
+
+const (
+	// maxRating is the highest rating a history record can have
+	maxRating = 5
+	// historyAnnotationsKey is the HistoryRecord.Metadata key holding annotations
+	historyAnnotationsKey = "annotations"
+)
+
+// StarHistoryRecord marks or unmarks a history record as a favorite
+func (a *App) StarHistoryRecord(recordID string, starred bool) (*HistoryRecord, error) {
+	return a.annotateHistoryRecord(recordID, func(annotations *HistoryAnnotations) {
+		annotations.Starred = starred
+	})
+}
+
+// RateHistoryRecord sets a 1-5 rating on a history record; 0 clears the rating
+func (a *App) RateHistoryRecord(recordID string, rating int) (*HistoryRecord, error) {
+	if rating < 0 || rating > maxRating {
+		return nil, fmt.Errorf("rating must be between 0 and %d", maxRating)
+	}
+	return a.annotateHistoryRecord(recordID, func(annotations *HistoryAnnotations) {
+		annotations.Rating = rating
+	})
+}
+
+// SetHistoryNote attaches a free-text note to a history record
+func (a *App) SetHistoryNote(recordID string, note string) (*HistoryRecord, error) {
+	return a.annotateHistoryRecord(recordID, func(annotations *HistoryAnnotations) {
+		annotations.Note = strings.TrimSpace(note)
+	})
+}
+
+// SetHistoryLabels replaces the labels of a history record
+func (a *App) SetHistoryLabels(recordID string, labels []string) (*HistoryRecord, error) {
+	return a.annotateHistoryRecord(recordID, func(annotations *HistoryAnnotations) {
+		annotations.Labels = normalizeTags(labels)
+	})
+}
+
+// GetHistoryLabels returns every history label with the number of records using it,
+// most used first
+func (a *App) GetHistoryLabels() ([]TagCount, error) {
+	labels := make([]TagCount, 0)
+	err := a.withHistoryCache(func(cache *historyCache) {
+		for label, ids := range cache.labels {
+			labels = append(labels, TagCount{Tag: label, Count: len(ids)})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Count != labels[j].Count {
+			return labels[i].Count > labels[j].Count
+		}
+		return labels[i].Tag < labels[j].Tag
+	})
+
+	return labels, nil
+}
+
+// Helper Methods
+
+// annotateHistoryRecord applies change to the annotations of a record
+func (a *App) annotateHistoryRecord(recordID string, change func(annotations *HistoryAnnotations)) (*HistoryRecord, error) {
+	return a.updateHistoryRecord(recordID, func(record *HistoryRecord) {
+		annotations := recordAnnotations(*record)
+		change(&annotations)
+		setRecordAnnotations(record, annotations)
+	})
+}
+
+// recordAnnotations returns the annotations in a record's metadata. They are held as
+// HistoryAnnotations after an update and as decoded JSON after loading from disk.
+func recordAnnotations(record HistoryRecord) HistoryAnnotations {
+	var annotations HistoryAnnotations
+	switch value := record.Metadata[historyAnnotationsKey].(type) {
+	case nil:
+	case HistoryAnnotations:
+		annotations = value
+	default:
+		if data, err := json.Marshal(value); err == nil {
+			_ = json.Unmarshal(data, &annotations)
+		}
+	}
+	return annotations
+}
+
+// setRecordAnnotations stores annotations in a record's metadata, dropping the key
+// when nothing is annotated. The metadata map is replaced rather than modified.
+func setRecordAnnotations(record *HistoryRecord, annotations HistoryAnnotations) {
+	metadata := make(map[string]interface{}, len(record.Metadata)+1)
+	for key, value := range record.Metadata {
+		metadata[key] = value
+	}
+
+	if annotations.Starred || annotations.Rating > 0 || annotations.Note != "" || len(annotations.Labels) > 0 {
+		metadata[historyAnnotationsKey] = annotations
+	} else {
+		delete(metadata, historyAnnotationsKey)
+	}
+	record.Metadata = metadata
+}
@@ -0,0 +1,509 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// History Log Methods
+//
+// History is stored as an append-only JSON Lines log (ai-history.jsonl). Each line
+// either puts a record (insert or replace by ID) or deletes one with a tombstone.
+// Adding a record only appends a line, and a crash mid-write can at worst lose the
+// last line. The log is compacted into one put per live record once it contains
+// enough superseded entries. A legacy ai-history.json is migrated on first load.
+
+const (
+	historyLogFile    = "ai-history.jsonl"
+	legacyHistoryFile = "ai-history.json"
+
+	historyOpPut    = "put"
+	historyOpDelete = "delete"
+
+	// compactMinGarbage is the number of superseded entries tolerated before compacting
+	compactMinGarbage = 200
+)
+
+// historyLogEntry is one line of the history log
+type historyLogEntry struct {
+	Op     string         `json:"op"`
+	ID     string         `json:"id,omitempty"`
+	Record *HistoryRecord `json:"record,omitempty"`
+}
+
+// CompactHistory rewrites the history log with one entry per live record
+func (a *App) CompactHistory() error {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+
+	history, _, err := a.readHistoryLogLocked()
+	if err != nil {
+		return err
+	}
+	return a.rewriteHistoryLogLocked(history)
+}
+
+// Helper Methods
+
+// readHistoryLog replays the history log, compacting it when it has accumulated
+// too many superseded entries
+func (a *App) readHistoryLog() ([]HistoryRecord, error) {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+
+	history, garbage, err := a.readHistoryLogLocked()
+	if err != nil {
+		return nil, err
+	}
+
+	if garbage >= compactMinGarbage && garbage > len(history) {
+		if err := a.rewriteHistoryLogLocked(history); err != nil {
+			fmt.Printf("Warning: Failed to compact history log: %v\n", err)
+		}
+	}
+
+	return history, nil
+}
+
+// readHistoryLogLocked returns the live records, replaying the log into the in-memory
+// cache on first access, along with the number of superseded entries in the log.
+// Unreadable lines, such as a line truncated by a crash, are skipped.
+func (a *App) readHistoryLogLocked() ([]HistoryRecord, int, error) {
+	if a.historyCache != nil {
+		return a.historyCache.list(), a.historyCache.garbage(), nil
+	}
+
+	if err := a.migrateLegacyHistoryLocked(); err != nil {
+		return nil, 0, err
+	}
+
+	data, err := os.ReadFile(a.historyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			a.historyCache = newHistoryCache(nil)
+			return []HistoryRecord{}, 0, nil
+		}
+		return nil, 0, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	cache := newHistoryCache(nil)
+	entries := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry historyLogEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			continue
+		}
+		entries++
+		cache.apply(entry)
+	}
+
+	cache.entries = entries
+	a.historyCache = cache
+	return cache.list(), cache.garbage(), nil
+}
+
+// historyRecord returns the record with the given ID without listing all of history
+func (a *App) historyRecord(recordID string) (HistoryRecord, bool, error) {
+	var record HistoryRecord
+	var exists bool
+	err := a.withHistoryCache(func(cache *historyCache) {
+		record, exists = cache.get(recordID)
+	})
+	return record, exists, err
+}
+
+// updateHistoryRecord applies change to a record and appends the result to the log
+func (a *App) updateHistoryRecord(recordID string, change func(record *HistoryRecord)) (*HistoryRecord, error) {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+
+	if _, _, err := a.readHistoryLogLocked(); err != nil {
+		return nil, err
+	}
+
+	record, exists := a.historyCache.get(recordID)
+	if !exists {
+		return nil, fmt.Errorf("history record not found: %s", recordID)
+	}
+
+	change(&record)
+	if err := a.appendHistoryLogLocked(historyLogEntry{Op: historyOpPut, Record: &record}); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// withHistoryCache runs fn with the loaded history cache while holding the history lock
+func (a *App) withHistoryCache(fn func(cache *historyCache)) error {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+
+	if _, _, err := a.readHistoryLogLocked(); err != nil {
+		return err
+	}
+	fn(a.historyCache)
+	return nil
+}
+
+// resetHistoryCache drops the in-memory history so it is replayed from disk
+func (a *App) resetHistoryCache() {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	a.historyCache = nil
+}
+
+// appendHistoryLog appends entries to the log and syncs them to disk
+func (a *App) appendHistoryLog(entries ...historyLogEntry) error {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+	return a.appendHistoryLogLocked(entries...)
+}
+
+// appendHistoryLogLocked appends entries to the log and applies them to the cache
+func (a *App) appendHistoryLogLocked(entries ...historyLogEntry) error {
+	if err := a.migrateLegacyHistoryLocked(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history entry: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	file, err := os.OpenFile(a.historyPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	// Terminate a line left incomplete by a crash so it doesn't swallow the new entry
+	data := buf.Bytes()
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			data = append([]byte{'\n'}, data...)
+		}
+	}
+
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	if a.historyCache != nil {
+		for _, entry := range entries {
+			if entry.Record != nil {
+				// The caller keeps its record; the cache holds its own copy
+				record := cloneHistoryRecord(*entry.Record)
+				entry.Record = &record
+			}
+			a.historyCache.apply(entry)
+		}
+		a.historyCache.entries += len(entries)
+	}
+	return nil
+}
+
+// rewriteHistoryLog replaces the log with one put entry per record
+func (a *App) rewriteHistoryLog(history []HistoryRecord) error {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+
+	if err := a.migrateLegacyHistoryLocked(); err != nil {
+		return err
+	}
+	return a.rewriteHistoryLogLocked(history)
+}
+
+// rewriteHistoryLogLocked writes the log to a temporary file and renames it into
+// place so a crash never leaves a partially written history behind
+func (a *App) rewriteHistoryLogLocked(history []HistoryRecord) error {
+	var buf bytes.Buffer
+	for i := range history {
+		line, err := json.Marshal(historyLogEntry{Op: historyOpPut, Record: &history[i]})
+		if err != nil {
+			return fmt.Errorf("failed to marshal history: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	if err := writeFileAtomic(a.historyPath, buf.Bytes()); err != nil {
+		return err
+	}
+
+	records := make([]HistoryRecord, 0, len(history))
+	for _, record := range history {
+		records = append(records, cloneHistoryRecord(record))
+	}
+	a.historyCache = newHistoryCache(records)
+	return nil
+}
+
+// migrateLegacyHistoryLocked converts ai-history.json into the log format the first
+// time the log is accessed, keeping the old file as ai-history.json.bak. A file that
+// cannot be parsed is moved to ai-history.json.corrupt so it is only reported once.
+func (a *App) migrateLegacyHistoryLocked() error {
+	if _, err := os.Stat(a.historyPath); err == nil {
+		return nil
+	}
+
+	legacyPath := filepath.Join(a.dataRoot, legacyHistoryFile)
+	data, err := os.ReadFile(legacyPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read legacy history file: %w", err)
+	}
+
+	var history []HistoryRecord
+	if err := json.Unmarshal(data, &history); err != nil {
+		// Keep the unreadable file for manual recovery and start a fresh log
+		fmt.Printf("Warning: Failed to parse legacy history file, moved to %s.corrupt: %v\n", legacyHistoryFile, err)
+		if err := os.Rename(legacyPath, legacyPath+".corrupt"); err != nil {
+			return fmt.Errorf("failed to move unreadable legacy history file: %w", err)
+		}
+		return nil
+	}
+
+	if err := a.rewriteHistoryLogLocked(history); err != nil {
+		return err
+	}
+
+	if err := os.Rename(legacyPath, legacyPath+".bak"); err != nil {
+		fmt.Printf("Warning: Failed to rename legacy history file: %v\n", err)
+	}
+	return nil
+}
+
+// historyCache holds the live history records in log order together with indexes over
+// record annotations, so queries don't need to replay the log
+type historyCache struct {
+	records   []HistoryRecord
+	positions map[string]int             // record ID -> index in records
+	entries   int                        // Entries in the log file, including superseded ones
+	labels    map[string]map[string]bool // label -> record IDs
+	ratings   map[int]map[string]bool    // rating -> record IDs
+	starred   map[string]bool            // starred record IDs
+}
+
+// newHistoryCache builds a cache holding history
+func newHistoryCache(history []HistoryRecord) *historyCache {
+	c := &historyCache{
+		positions: make(map[string]int),
+		labels:    make(map[string]map[string]bool),
+		ratings:   make(map[int]map[string]bool),
+		starred:   make(map[string]bool),
+	}
+	for _, record := range history {
+		c.put(record)
+	}
+	c.entries = len(history)
+	return c
+}
+
+// apply replays a single log entry
+func (c *historyCache) apply(entry historyLogEntry) {
+	switch entry.Op {
+	case historyOpPut:
+		if entry.Record != nil {
+			c.put(*entry.Record)
+		}
+	case historyOpDelete:
+		c.delete(entry.ID)
+	}
+}
+
+// put inserts a record or replaces the record with the same ID in place
+func (c *historyCache) put(record HistoryRecord) {
+	if pos, exists := c.positions[record.ID]; exists {
+		c.unindex(c.records[pos])
+		c.records[pos] = record
+	} else {
+		c.positions[record.ID] = len(c.records)
+		c.records = append(c.records, record)
+	}
+	c.index(record)
+}
+
+// delete removes a record by ID
+func (c *historyCache) delete(id string) {
+	pos, exists := c.positions[id]
+	if !exists {
+		return
+	}
+
+	c.unindex(c.records[pos])
+	c.records = append(c.records[:pos], c.records[pos+1:]...)
+	delete(c.positions, id)
+	for i := pos; i < len(c.records); i++ {
+		c.positions[c.records[i].ID] = i
+	}
+}
+
+// get returns a deep copy of the record with the given ID
+func (c *historyCache) get(id string) (HistoryRecord, bool) {
+	pos, exists := c.positions[id]
+	if !exists {
+		return HistoryRecord{}, false
+	}
+	return cloneHistoryRecord(c.records[pos]), true
+}
+
+// list returns deep copies of the live records in log order
+func (c *historyCache) list() []HistoryRecord {
+	records := make([]HistoryRecord, 0, len(c.records))
+	for _, record := range c.records {
+		records = append(records, cloneHistoryRecord(record))
+	}
+	return records
+}
+
+// garbage returns the number of superseded entries in the log
+func (c *historyCache) garbage() int {
+	return c.entries - len(c.records)
+}
+
+// index adds a record's annotations to the indexes
+func (c *historyCache) index(record HistoryRecord) {
+	annotations := recordAnnotations(record)
+	for _, label := range annotations.Labels {
+		if c.labels[label] == nil {
+			c.labels[label] = make(map[string]bool)
+		}
+		c.labels[label][record.ID] = true
+	}
+	if annotations.Rating > 0 {
+		if c.ratings[annotations.Rating] == nil {
+			c.ratings[annotations.Rating] = make(map[string]bool)
+		}
+		c.ratings[annotations.Rating][record.ID] = true
+	}
+	if annotations.Starred {
+		c.starred[record.ID] = true
+	}
+}
+
+// unindex removes a record's annotations from the indexes
+func (c *historyCache) unindex(record HistoryRecord) {
+	annotations := recordAnnotations(record)
+	for _, label := range annotations.Labels {
+		delete(c.labels[label], record.ID)
+		if len(c.labels[label]) == 0 {
+			delete(c.labels, label)
+		}
+	}
+	delete(c.ratings[annotations.Rating], record.ID)
+	delete(c.starred, record.ID)
+}
+
+// cloneHistoryRecord returns a copy of record that shares no slices, maps or pointers
+// with it, so callers can change the copy without changing the cache
+func cloneHistoryRecord(record HistoryRecord) HistoryRecord {
+	clone := record
+	if record.Images != nil {
+		// Images has no omitempty, so an empty list must stay empty rather than nil
+		clone.Images = append(make([]GeneratedImage, 0, len(record.Images)), record.Images...)
+	}
+	clone.SourceRecords = append([]string(nil), record.SourceRecords...)
+	clone.Metadata = cloneJSONMap(record.Metadata)
+
+	params := &clone.Params
+	params.Parameters = cloneJSONMap(record.Params.Parameters)
+	params.ReferenceImages = append([]string(nil), record.Params.ReferenceImages...)
+	if record.Params.Selections != nil {
+		params.Selections = make(map[string]string, len(record.Params.Selections))
+		for key, value := range record.Params.Selections {
+			params.Selections[key] = value
+		}
+	}
+	if record.Params.Seed != nil {
+		seed := *record.Params.Seed
+		params.Seed = &seed
+	}
+	if record.Params.PostProcess != nil {
+		pipeline := *record.Params.PostProcess
+		pipeline.Steps = append([]PostProcessStep(nil), pipeline.Steps...)
+		params.PostProcess = &pipeline
+	}
+	return clone
+}
+
+// cloneJSONMap deep-copies a map holding decoded JSON values
+func cloneJSONMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]interface{}, len(m))
+	for key, value := range m {
+		clone[key] = cloneJSONValue(value)
+	}
+	return clone
+}
+
+// cloneJSONValue deep-copies a decoded JSON value. Other values are copied shallowly,
+// except HistoryAnnotations whose labels are copied too.
+func cloneJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return cloneJSONMap(v)
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, item := range v {
+			clone[i] = cloneJSONValue(item)
+		}
+		return clone
+	case []string:
+		return append([]string(nil), v...)
+	case HistoryAnnotations:
+		v.Labels = append([]string(nil), v.Labels...)
+		return v
+	}
+	return value
+}
+
+// writeFileAtomic writes data to a temporary file, syncs it and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	return os.Rename(tmpPath, path)
+}
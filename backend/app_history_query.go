@@ -0,0 +1,211 @@
+package backend
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// History Query Methods
+
+const (
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 500
+)
+
+// QueryHistory returns one page of history records matching the query, sorted by timestamp.
+// Pass the returned NextCursor to fetch the following page.
+func (a *App) QueryHistory(query HistoryQuery) (*HistoryPage, error) {
+	// Narrow candidates with the annotation indexes, then check every filter
+	matched := make([]HistoryRecord, 0)
+	totalAll := 0
+	err := a.withHistoryCache(func(cache *historyCache) {
+		candidates := cache.candidates(query)
+		totalAll = len(cache.records)
+		for _, record := range cache.records {
+			if candidates != nil && !candidates[record.ID] {
+				continue
+			}
+			if matchHistoryQuery(record, query) {
+				matched = append(matched, record)
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultHistoryPageSize
+	}
+	if limit > maxHistoryPageSize {
+		limit = maxHistoryPageSize
+	}
+
+	oldestFirst := query.Sort == "oldest"
+	sort.SliceStable(matched, func(i, j int) bool {
+		return historyBefore(matched[i], matched[j], oldestFirst)
+	})
+
+	// Skip everything up to and including the cursor position
+	start := 0
+	if query.Cursor != "" {
+		cursor, err := decodeHistoryCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(matched), func(i int) bool {
+			return historyBefore(cursor, matched[i], oldestFirst)
+		})
+	}
+
+	end := start + limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	// Records still share slices and maps with the cache until copied
+	records := make([]HistoryRecord, 0, end-start)
+	for _, record := range matched[start:end] {
+		records = append(records, cloneHistoryRecord(record))
+	}
+
+	page := &HistoryPage{
+		Records:  records,
+		Total:    len(matched),
+		TotalAll: totalAll,
+	}
+	if end < len(matched) {
+		page.NextCursor = encodeHistoryCursor(matched[end-1])
+	}
+
+	return page, nil
+}
+
+// Helper Methods
+
+// matchHistoryQuery reports whether a record passes every filter in query
+func matchHistoryQuery(record HistoryRecord, query HistoryQuery) bool {
+	params := record.Params
+	if query.Provider != "" && params.Provider != query.Provider {
+		return false
+	}
+	if query.Model != "" && params.Model != query.Model {
+		return false
+	}
+	if query.Size != "" && params.Size != query.Size {
+		return false
+	}
+	if query.TemplateID != "" && params.TemplateID != query.TemplateID {
+		return false
+	}
+	if query.From != 0 && record.Timestamp < query.From {
+		return false
+	}
+	if query.To != 0 && record.Timestamp > query.To {
+		return false
+	}
+	if query.HasImages != nil && (len(record.Images) > 0) != *query.HasImages {
+		return false
+	}
+	if query.Prompt != "" && !strings.Contains(strings.ToLower(params.Prompt), strings.ToLower(query.Prompt)) {
+		return false
+	}
+	if query.Starred == nil && query.MinRating == 0 && len(query.Labels) == 0 {
+		return true
+	}
+
+	annotations := recordAnnotations(record)
+	if query.Starred != nil && annotations.Starred != *query.Starred {
+		return false
+	}
+	if query.MinRating > 0 && annotations.Rating < query.MinRating {
+		return false
+	}
+	if len(query.Labels) > 0 && !matchTags(annotations.Labels, normalizeTags(query.Labels), false) {
+		return false
+	}
+	return true
+}
+
+// candidates returns the IDs of records that can match the query's annotation filters
+// according to the indexes, or nil when the query has no annotation filters
+func (c *historyCache) candidates(query HistoryQuery) map[string]bool {
+	var result map[string]bool
+	narrow := func(ids map[string]bool) {
+		if result == nil {
+			result = make(map[string]bool, len(ids))
+			for id := range ids {
+				result[id] = true
+			}
+			return
+		}
+		for id := range result {
+			if !ids[id] {
+				delete(result, id)
+			}
+		}
+	}
+
+	if query.Starred != nil && *query.Starred {
+		narrow(c.starred)
+	}
+	if query.MinRating > 0 {
+		rated := make(map[string]bool)
+		for rating := query.MinRating; rating <= maxRating; rating++ {
+			for id := range c.ratings[rating] {
+				rated[id] = true
+			}
+		}
+		narrow(rated)
+	}
+	for _, label := range normalizeTags(query.Labels) {
+		narrow(c.labels[label])
+	}
+
+	return result
+}
+
+// historyBefore reports whether record a sorts before b. Records are ordered by
+// timestamp, newest first unless oldestFirst, with the ID breaking ties.
+func historyBefore(a, b HistoryRecord, oldestFirst bool) bool {
+	if a.Timestamp != b.Timestamp {
+		if oldestFirst {
+			return a.Timestamp < b.Timestamp
+		}
+		return a.Timestamp > b.Timestamp
+	}
+	if oldestFirst {
+		return a.ID < b.ID
+	}
+	return a.ID > b.ID
+}
+
+// encodeHistoryCursor builds an opaque cursor pointing at record
+func encodeHistoryCursor(record HistoryRecord) string {
+	raw := strconv.FormatInt(record.Timestamp, 10) + "|" + record.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeHistoryCursor parses a cursor into the timestamp and ID it points at
+func decodeHistoryCursor(cursor string) (HistoryRecord, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return HistoryRecord{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return HistoryRecord{}, fmt.Errorf("invalid cursor")
+	}
+
+	timestamp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return HistoryRecord{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+
+	return HistoryRecord{ID: parts[1], Timestamp: timestamp}, nil
+}
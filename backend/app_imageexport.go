@@ -0,0 +1,427 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	wailsruntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Bulk Image Export Methods
+//
+// ExportImages copies selected history images and template covers into a folder,
+// naming each file from a pattern such as "{date}_{template}_{index}". Supported
+// tokens are {date}, {time}, {template}, {model}, {provider}, {size}, {seed}, {index},
+// {record} and {prompt} or {prompt:N}, a file name safe snippet of N characters.
+
+const (
+	// imageExportProgressEvent is emitted with an ImageExportProgress after every image
+	imageExportProgressEvent = "image-export:progress"
+
+	defaultExportPattern = "{date}_{template}_{index}"
+	// defaultPromptSnippet is the length of {prompt}
+	defaultPromptSnippet = 40
+	// maxExportNameLength keeps generated names well inside file system limits
+	maxExportNameLength = 150
+)
+
+// exportTokenPattern matches {token} and {token:N}
+var exportTokenPattern = regexp.MustCompile(`\{([a-z]+)(?::(\d+))?\}`)
+
+// exportTokens lists the tokens a pattern may use
+var exportTokens = map[string]bool{
+	"date": true, "time": true, "template": true, "model": true, "provider": true,
+	"size": true, "seed": true, "index": true, "record": true, "prompt": true,
+}
+
+// exportItem is one image selected for a bulk export
+type exportItem struct {
+	ref      string
+	imageID  string
+	record   *HistoryRecord
+	template *Template
+}
+
+// ExportImages writes the selected images to a folder with pattern-based names,
+// optionally with a .txt or .json sidecar per image. Progress is emitted as
+// "image-export:progress" events; missing images are reported instead of failing.
+func (a *App) ExportImages(options ImageExportOptions) (*ImageExportResult, error) {
+	pattern := options.Pattern
+	if pattern == "" {
+		pattern = defaultExportPattern
+	}
+	if err := validateExportPattern(pattern); err != nil {
+		return nil, err
+	}
+	switch options.Sidecar {
+	case "", "txt", "json":
+	default:
+		return nil, fmt.Errorf("unknown sidecar format: %s", options.Sidecar)
+	}
+
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+	items, err := a.selectExportImages(options, templates)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no images selected")
+	}
+
+	destination := options.Destination
+	if destination == "" {
+		if destination, err = a.chooseExportDestination(false); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(destination, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export folder: %w", err)
+	}
+
+	templateNames := make(map[string]string, len(templates))
+	for _, t := range templates {
+		templateNames[t.ID] = templateDisplayName(t, "")
+	}
+
+	result := &ImageExportResult{Path: destination, Files: []string{}, Missing: []ExportIssue{}}
+	digits := len(strconv.Itoa(len(items)))
+	// Names written by this export, so images never replace each other
+	claimed := make(map[string]bool, len(items))
+
+	for i, item := range items {
+		progress := ImageExportProgress{Done: i + 1, Total: len(items)}
+
+		file, err := a.exportImage(item, destination, pattern, i+1, digits, templateNames, claimed, options)
+		if err != nil {
+			issue := ExportIssue{Path: item.ref, Reason: err.Error()}
+			if item.record != nil {
+				issue.RecordID = item.record.ID
+			}
+			result.Missing = append(result.Missing, issue)
+			progress.Error = err.Error()
+		} else {
+			result.Files = append(result.Files, file)
+			result.Exported++
+			progress.File = file
+		}
+
+		if a.ctx != nil {
+			wailsruntime.EventsEmit(a.ctx, imageExportProgressEvent, progress)
+		}
+	}
+
+	return result, nil
+}
+
+// Helper Methods
+
+// selectExportImages collects the images of the selected records, the selected
+// history images and the covers of the selected templates in the order they were
+// selected, without repeats
+func (a *App) selectExportImages(options ImageExportOptions, templates []Template) ([]exportItem, error) {
+	var items []exportItem
+	seen := make(map[string]bool)
+	add := func(item exportItem) {
+		key := item.ref
+		if item.record != nil {
+			key = item.record.ID + "\x00" + item.imageID + "\x00" + item.ref
+		}
+		if !seen[key] {
+			seen[key] = true
+			items = append(items, item)
+		}
+	}
+
+	if len(options.RecordIDs) > 0 || len(options.ImageIDs) > 0 {
+		history, err := a.LoadAIHistory()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load history: %w", err)
+		}
+
+		records := make(map[string]*HistoryRecord, len(history))
+		images := make(map[string][]exportItem)
+		for i := range history {
+			record := &history[i]
+			records[record.ID] = record
+			for _, img := range record.Images {
+				images[img.ID] = append(images[img.ID], exportItem{ref: img.URL, imageID: img.ID, record: record})
+			}
+		}
+
+		for _, id := range options.RecordIDs {
+			if record, ok := records[id]; ok {
+				for _, img := range record.Images {
+					add(exportItem{ref: img.URL, imageID: img.ID, record: record})
+				}
+			}
+		}
+		for _, id := range options.ImageIDs {
+			for _, item := range images[id] {
+				add(item)
+			}
+		}
+	}
+
+	if len(options.TemplateIDs) > 0 {
+		byID := make(map[string]*Template, len(templates))
+		for i := range templates {
+			byID[templates[i].ID] = &templates[i]
+		}
+		for _, id := range options.TemplateIDs {
+			t, ok := byID[id]
+			if !ok {
+				continue
+			}
+			for _, ref := range append([]string{t.ImageURL}, t.ImageURLs...) {
+				if ref != "" {
+					add(exportItem{ref: ref, template: t})
+				}
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// exportImage writes one image and its sidecar, returning the image file name.
+// claimed holds the names already used by this export.
+func (a *App) exportImage(item exportItem, dir, pattern string, index, digits int, templateNames map[string]string, claimed map[string]bool, options ImageExportOptions) (string, error) {
+	data, ext, err := a.readExportImage(item.ref)
+	if err != nil {
+		return "", err
+	}
+
+	name := expandExportPattern(pattern, item, index, digits, templateNames)
+	sidecarExt := ""
+	if options.Sidecar != "" {
+		sidecarExt = "." + options.Sidecar
+	}
+
+	base := uniqueExportBase(filepath.Join(dir, name), ext, sidecarExt, claimed, options.Overwrite)
+
+	if err := os.WriteFile(base+ext, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write image: %w", err)
+	}
+
+	if sidecarExt != "" {
+		sidecar, err := exportSidecar(item, options.Sidecar)
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(base+sidecarExt, sidecar, 0644); err != nil {
+			return "", fmt.Errorf("failed to write sidecar: %w", err)
+		}
+	}
+
+	return filepath.Base(base) + ext, nil
+}
+
+// validateExportPattern rejects unknown tokens and lengths on tokens that take none
+func validateExportPattern(pattern string) error {
+	for _, match := range exportTokenPattern.FindAllStringSubmatch(pattern, -1) {
+		if !exportTokens[match[1]] {
+			return fmt.Errorf("unknown pattern token: {%s}", match[1])
+		}
+		if match[2] != "" && match[1] != "prompt" {
+			return fmt.Errorf("only {prompt} takes a length: %s", match[0])
+		}
+	}
+	return nil
+}
+
+// expandExportPattern fills the tokens of pattern for one image and makes the result
+// safe to use as a file name (without extension)
+func expandExportPattern(pattern string, item exportItem, index, digits int, templateNames map[string]string) string {
+	var params GenerationParams
+	var timestamp int64
+	recordID := ""
+	if item.record != nil {
+		params = item.record.Params
+		timestamp = item.record.Timestamp
+		recordID = item.record.ID
+	}
+	templateID := params.TemplateID
+	prompt := params.Prompt
+	if item.template != nil {
+		templateID = item.template.ID
+		prompt = templateDisplayName(*item.template, "")
+	}
+
+	when := time.Now()
+	if timestamp > 0 {
+		when = time.Unix(timestamp, 0)
+	}
+
+	name := exportTokenPattern.ReplaceAllStringFunc(pattern, func(token string) string {
+		match := exportTokenPattern.FindStringSubmatch(token)
+		switch match[1] {
+		case "date":
+			return when.Format("2006-01-02")
+		case "time":
+			return when.Format("150405")
+		case "template":
+			if name, ok := templateNames[templateID]; ok && name != "" {
+				return name
+			}
+			return templateID
+		case "model":
+			return params.Model
+		case "provider":
+			return params.Provider
+		case "size":
+			return strings.ReplaceAll(params.Size, "*", "x")
+		case "seed":
+			if params.Seed != nil {
+				return strconv.FormatInt(*params.Seed, 10)
+			}
+		case "index":
+			return fmt.Sprintf("%0*d", digits, index)
+		case "record":
+			return recordID
+		case "prompt":
+			length := defaultPromptSnippet
+			if match[2] != "" {
+				length, _ = strconv.Atoi(match[2])
+			}
+			return promptSnippet(prompt, length)
+		}
+		return ""
+	})
+
+	return cleanExportName(name, index, digits)
+}
+
+// promptSnippet returns up to length characters of the words of prompt joined by
+// dashes, dropping punctuation
+func promptSnippet(prompt string, length int) string {
+	words := strings.FieldsFunc(prompt, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	snippet := []rune(strings.Join(words, "-"))
+	if len(snippet) > length {
+		snippet = snippet[:length]
+	}
+	return strings.Trim(string(snippet), "-")
+}
+
+// cleanExportName sanitizes an expanded pattern, collapsing the separators left by
+// empty tokens. An empty result falls back to the index.
+func cleanExportName(name string, index, digits int) string {
+	name = sanitizeFileName(name)
+	for _, sep := range []string{"__", "--", "  "} {
+		for strings.Contains(name, sep) {
+			name = strings.ReplaceAll(name, sep, sep[:1])
+		}
+	}
+	name = strings.Trim(name, "_- .")
+
+	if runes := []rune(name); len(runes) > maxExportNameLength {
+		name = strings.TrimRight(string(runes[:maxExportNameLength]), "_- .")
+	}
+	if name == "" {
+		name = fmt.Sprintf("%0*d", digits, index)
+	}
+	// Trimming can leave a reserved name such as "CON" behind, so check once more
+	return sanitizeFileName(name)
+}
+
+// uniqueExportBase appends a counter to base until neither the image nor its sidecar
+// is taken, and claims the result. Names claimed earlier in the export are always
+// taken; files that existed before it are taken unless overwrite is set. Names are
+// compared case-insensitively, as Windows and macOS do.
+func uniqueExportBase(base, ext, sidecarExt string, claimed map[string]bool, overwrite bool) string {
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	taken := func(candidate string) bool {
+		if claimed[strings.ToLower(candidate)] {
+			return true
+		}
+		if overwrite {
+			return false
+		}
+		return exists(candidate+ext) || (sidecarExt != "" && exists(candidate+sidecarExt))
+	}
+
+	candidate := base
+	for i := 1; taken(candidate); i++ {
+		candidate = fmt.Sprintf("%s_%d", base, i)
+	}
+	claimed[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// exportSidecar renders the metadata file written next to an exported image
+func exportSidecar(item exportItem, format string) ([]byte, error) {
+	if format == "json" {
+		sidecar := map[string]interface{}{"source": item.ref}
+		if item.record != nil {
+			sidecar["recordId"] = item.record.ID
+			sidecar["imageId"] = item.imageID
+			sidecar["timestamp"] = item.record.Timestamp
+			sidecar["params"] = item.record.Params
+		}
+		if item.template != nil {
+			sidecar["templateId"] = item.template.ID
+			sidecar["name"] = item.template.Name
+			sidecar["content"] = item.template.Content
+		}
+
+		data, err := json.MarshalIndent(sidecar, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal sidecar: %w", err)
+		}
+		return data, nil
+	}
+
+	// Plain text uses the AUTOMATIC1111 parameters layout other tools understand
+	if item.record != nil {
+		return []byte(formatA1111Parameters(metadataFromParams(&item.record.Params)) + "\n"), nil
+	}
+
+	var b strings.Builder
+	b.WriteString(templateDisplayName(*item.template, "") + "\n")
+	languages := make([]string, 0, len(item.template.Content))
+	for lang := range item.template.Content {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	for _, lang := range languages {
+		fmt.Fprintf(&b, "\n[%s]\n%s\n", lang, item.template.Content[lang])
+	}
+	return []byte(b.String()), nil
+}
+
+// templateDisplayName returns the template name in language, falling back to
+// English, then any language, then the ID
+func templateDisplayName(t Template, language string) string {
+	for _, lang := range []string{language, "en"} {
+		if name := t.Name[lang]; lang != "" && name != "" {
+			return name
+		}
+	}
+
+	languages := make([]string, 0, len(t.Name))
+	for lang := range t.Name {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	for _, lang := range languages {
+		if t.Name[lang] != "" {
+			return t.Name[lang]
+		}
+	}
+	return t.ID
+}
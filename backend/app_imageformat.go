@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"time"
+)
+
+// Image Storage Format Methods
+//
+// Generated images are stored in the format chosen in the storage settings. When the
+// source is already in that format and needs no resizing its bytes are kept as they
+// are, so embedded chunks and ICC profiles survive. Generation metadata can only be
+// embedded in PNGs; other formats keep it in the history record alone.
+
+const (
+	storageFormatOriginal = "original"
+	storageFormatPNG      = "png"
+	storageFormatJPEG     = "jpeg"
+
+	defaultJPEGQuality = 90
+)
+
+// imageEncoder encodes an image; quality is only used by lossy formats
+type imageEncoder func(w io.Writer, img image.Image, quality int) error
+
+// imageEncoders lists the formats images can be converted to
+var imageEncoders = map[string]imageEncoder{
+	storageFormatPNG: func(w io.Writer, img image.Image, quality int) error {
+		return png.Encode(w, img)
+	},
+	storageFormatJPEG: func(w io.Writer, img image.Image, quality int) error {
+		return jpeg.Encode(w, flattenImage(img), &jpeg.Options{Quality: quality})
+	},
+}
+
+// GetStorageSettings returns the image storage format settings
+func (a *App) GetStorageSettings() (*StorageSettings, error) {
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	settings := normalizeStorageSettings(config.Storage)
+	return &settings, nil
+}
+
+// SetStorageSettings saves the image storage format settings
+func (a *App) SetStorageSettings(settings StorageSettings) error {
+	switch settings.Format {
+	case "", storageFormatOriginal:
+	default:
+		if _, ok := imageEncoders[settings.Format]; !ok {
+			return fmt.Errorf("no encoder available for image format: %s", settings.Format)
+		}
+	}
+	if settings.JPEGQuality < 0 || settings.JPEGQuality > 100 {
+		return fmt.Errorf("JPEG quality must be 0 (default) or 1-100")
+	}
+	if settings.MaxDimension < 0 {
+		return fmt.Errorf("max dimension cannot be negative")
+	}
+
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	config.Storage = normalizeStorageSettings(settings)
+	config.UpdatedAt = time.Now()
+	return a.saveConfig(config)
+}
+
+// Helper Methods
+
+// storageSettings returns the storage settings, falling back to defaults on error
+func (a *App) storageSettings() StorageSettings {
+	settings, err := a.GetStorageSettings()
+	if err != nil {
+		return normalizeStorageSettings(StorageSettings{})
+	}
+	return *settings
+}
+
+// normalizeStorageSettings fills in defaults
+func normalizeStorageSettings(settings StorageSettings) StorageSettings {
+	if settings.Format == "" {
+		settings.Format = storageFormatPNG
+	}
+	if settings.JPEGQuality == 0 {
+		settings.JPEGQuality = defaultJPEGQuality
+	}
+	return settings
+}
+
+// encodeForStorage converts image data to the configured storage format and embeds
+// meta into PNG output. Data already in the target format that needs no resizing
+// keeps its bytes apart from the metadata chunks; PassThrough is only reported when
+// the output is identical to the source.
+func encodeForStorage(data []byte, settings StorageSettings, meta *ImageMetadata) ([]byte, *ImageEncoding, error) {
+	config, sourceFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	width, height := fitWithin(config.Width, config.Height, settings.MaxDimension)
+	resize := width != config.Width || height != config.Height
+
+	target := settings.Format
+	if target == storageFormatOriginal {
+		target = sourceFormat
+		if _, ok := imageEncoders[target]; resize && !ok {
+			// The source format can't be re-encoded, so resized output becomes PNG
+			target = storageFormatPNG
+		}
+	}
+
+	encoding := &ImageEncoding{SourceFormat: sourceFormat}
+
+	var out []byte
+	if target == sourceFormat && !resize {
+		encoding.PassThrough = true
+		out = data
+	} else {
+		encode, ok := imageEncoders[target]
+		if !ok {
+			return nil, nil, fmt.Errorf("no encoder available for image format: %s", target)
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode image (format: %s): %w", sourceFormat, err)
+		}
+		if resize {
+			img = resampleImage(img, width, height)
+			encoding.Resized = true
+		}
+
+		var buf bytes.Buffer
+		if err := encode(&buf, img, settings.JPEGQuality); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode image as %s: %w", target, err)
+		}
+		if target == storageFormatJPEG {
+			encoding.Quality = settings.JPEGQuality
+		}
+		out = buf.Bytes()
+	}
+
+	if target == storageFormatPNG && meta != nil {
+		out, err = embedPNGMetadata(out, meta)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to embed image metadata: %w", err)
+		}
+		encoding.Metadata = true
+		// Embedding rewrites the chunks, so the source bytes are no longer kept as they are
+		encoding.PassThrough = encoding.PassThrough && bytes.Equal(out, data)
+	}
+
+	return out, encoding, nil
+}
+
+// metadataDroppedMessage explains that generation metadata was not embedded in an image
+func metadataDroppedMessage(format string) string {
+	return fmt.Sprintf("generation metadata can't be embedded in %s images and is only kept in the history record", format)
+}
+
+// flattenImage composites transparent images onto white for formats without alpha
+func flattenImage(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	b := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+	return flat
+}
@@ -0,0 +1,620 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Image Store Methods
+//
+// Images are stored under <data root>/images named by the SHA-256 of their content,
+// so saving the same image twice only writes it once. images.json keeps metadata
+// and the list of owners (history records, template covers) referencing each image.
+
+const imageIndexFile = "images.json"
+
+// errCorruptImageIndex is returned while images.json cannot be parsed, so nothing
+// overwrites or garbage collects the references it holds until it is rebuilt
+var errCorruptImageIndex = errors.New("image index is corrupt")
+
+// imageIndex maps image IDs to their metadata
+type imageIndex map[string]*StoredImage
+
+// GetImageInfo returns metadata for a stored image
+func (a *App) GetImageInfo(imageID string) (*StoredImage, error) {
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	index, err := a.loadImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	info, exists := index[imageID]
+	if !exists {
+		return nil, fmt.Errorf("image not found: %s", imageID)
+	}
+	return info, nil
+}
+
+// ResolveImagePath returns the absolute file path of a stored image
+func (a *App) ResolveImagePath(imageID string) (string, error) {
+	info, err := a.GetImageInfo(imageID)
+	if err != nil {
+		return "", err
+	}
+	return a.resolveImagePath(info.Path), nil
+}
+
+// RebuildImageIndex rebuilds images.json from the stored files and the images that
+// history and templates reference. A corrupt index is moved aside first. Images saved
+// outside the store by older versions are added to it and their references rewritten,
+// so identical images share one file.
+func (a *App) RebuildImageIndex() (*ImageMigrationReport, error) {
+	if err := a.recoverImageIndex(); err != nil {
+		return nil, err
+	}
+
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	report := &ImageMigrationReport{}
+	adopted := make(map[string]string) // Legacy file path to store reference
+	adopt := func(ref *string, owner string) bool {
+		if !isLocalImageRef(*ref) || imageIDFromRef(*ref) != "" {
+			return false
+		}
+		path := filepath.Clean(a.resolveImagePath(*ref))
+		stored, ok := adopted[path]
+		if !ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				report.Missing = append(report.Missing, *ref)
+				return false
+			}
+			info, err := a.storeImage(data, owner)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", *ref, err))
+				return false
+			}
+			stored = info.Path
+			adopted[path] = stored
+			report.Moved++
+		}
+		*ref = stored
+		report.RewrittenPaths++
+		return true
+	}
+
+	historyChanged := false
+	for i := range history {
+		owner := historyImageOwner(history[i].ID)
+		for j := range history[i].Images {
+			historyChanged = adopt(&history[i].Images[j].URL, owner) || historyChanged
+		}
+		for j := range history[i].Params.ReferenceImages {
+			historyChanged = adopt(&history[i].Params.ReferenceImages[j], owner) || historyChanged
+		}
+	}
+	if historyChanged {
+		if err := a.SaveAIHistory(history); err != nil {
+			return nil, fmt.Errorf("failed to save history: %w", err)
+		}
+	}
+
+	templatesChanged := false
+	for i := range templates {
+		owner := templateImageOwner(templates[i].ID)
+		templatesChanged = adopt(&templates[i].ImageURL, owner) || templatesChanged
+		for j := range templates[i].ImageURLs {
+			templatesChanged = adopt(&templates[i].ImageURLs[j], owner) || templatesChanged
+		}
+	}
+	if templatesChanged {
+		if err := a.SaveTemplates(templates); err != nil {
+			return nil, fmt.Errorf("failed to save templates: %w", err)
+		}
+	}
+
+	// The store now holds a copy of every adopted file inside the images folder
+	for path := range adopted {
+		if a.legacyImageFile(path) == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		a.removeThumbnails(path)
+	}
+
+	if err := a.syncImageIndex(a.referencedImagePaths(history, templates)); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Helper Methods
+
+// recoverImageIndex moves a corrupt images.json aside and registers the stored files
+// found in the images folder in a fresh index. References are filled in afterwards.
+func (a *App) recoverImageIndex() error {
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	_, err := a.loadImageIndex()
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, errCorruptImageIndex) {
+		return err
+	}
+
+	indexPath := filepath.Join(a.dataRoot, imageIndexFile)
+	aside := fmt.Sprintf("%s.corrupt-%d", indexPath, time.Now().Unix())
+	if err := os.Rename(indexPath, aside); err != nil {
+		return fmt.Errorf("failed to move corrupt image index aside: %w", err)
+	}
+	fmt.Printf("Warning: Moved corrupt image index to %s\n", aside)
+
+	index := make(imageIndex)
+	entries, _ := os.ReadDir(a.imagesDir())
+	for _, entry := range entries {
+		id := imageIDFromRef(entry.Name())
+		if entry.IsDir() || id == "" {
+			continue
+		}
+		path := filepath.Join(a.imagesDir(), entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		format, width, height := detectImageFormat(data)
+		createdAt := time.Now().Unix()
+		if stat, err := entry.Info(); err == nil {
+			createdAt = stat.ModTime().Unix()
+		}
+		index[id] = &StoredImage{
+			ID:        id,
+			Path:      a.toImageRef(path),
+			Format:    format,
+			Width:     width,
+			Height:    height,
+			Size:      int64(len(data)),
+			CreatedAt: createdAt,
+			Refs:      []string{},
+		}
+	}
+	return a.saveImageIndex(index)
+}
+
+// imageIndexCorrupt reports whether images.json exists but cannot be parsed
+func (a *App) imageIndexCorrupt() bool {
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	_, err := a.loadImageIndex()
+	return errors.Is(err, errCorruptImageIndex)
+}
+
+// storeImage writes image bytes into the store, reusing the existing file when the
+// same content is already stored, and records owner as a reference
+func (a *App) storeImage(data []byte, owner string) (*StoredImage, error) {
+	return a.storeEncodedImage(data, owner, nil)
+}
+
+// storeEncodedImage stores image bytes like storeImage and records how they were produced
+func (a *App) storeEncodedImage(data []byte, owner string, encoding *ImageEncoding) (*StoredImage, error) {
+	id := imageContentID(data)
+
+	// Hashing decodes the image, so it happens before taking the lock
+	hash, hashErr := hashImageData(data)
+
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	index, err := a.loadImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	info, exists := index[id]
+	if exists {
+		// Re-create the file if it went missing
+		if _, err := os.Stat(a.resolveImagePath(info.Path)); err != nil {
+			exists = false
+		}
+	}
+
+	if !exists {
+		format, width, height := detectImageFormat(data)
+
+		imagesDir := a.imagesDir()
+		if err := os.MkdirAll(imagesDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create images directory: %w", err)
+		}
+
+		localPath := filepath.Join(imagesDir, id+imageExtension(format))
+		if err := writeFileAtomic(localPath, data); err != nil {
+			return nil, fmt.Errorf("failed to write image file: %w", err)
+		}
+
+		refs := []string{}
+		if info != nil {
+			refs = info.Refs
+		}
+
+		info = &StoredImage{
+			ID:        id,
+			Path:      a.toImageRef(localPath),
+			Format:    format,
+			Width:     width,
+			Height:    height,
+			Size:      int64(len(data)),
+			CreatedAt: time.Now().Unix(),
+			Refs:      refs,
+		}
+		if hashErr == nil {
+			info.Hash = hash.encode()
+		} else {
+			info.HashFailed = true
+		}
+		index[id] = info
+	}
+	if exists && encoding != nil && encoding.Metadata && (info.Encoding == nil || !info.Encoding.Metadata) {
+		// The same picture was stored without metadata first; keep the copy that has it.
+		// The file is replaced atomically as readers may be serving it.
+		if err := writeFileAtomic(a.resolveImagePath(info.Path), data); err != nil {
+			return nil, fmt.Errorf("failed to write image file: %w", err)
+		}
+		info.Size = int64(len(data))
+		info.Encoding = encoding
+	}
+	if info.Encoding == nil {
+		info.Encoding = encoding
+	}
+
+	info.Refs = addRef(info.Refs, owner)
+
+	if err := a.saveImageIndex(index); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// imageContentID returns the store ID of image bytes. Generation metadata embedded in
+// PNGs is left out, so a picture keeps one ID whether or not it carries metadata.
+func imageContentID(data []byte) string {
+	if bytes.HasPrefix(data, pngSignature) {
+		data = stripPNGTextChunks(data, pngParametersKeyword, pngSparkPromptKeyword)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// addImageRef records owner as a reference to the stored image behind ref.
+// References to images that are not in the store are ignored.
+func (a *App) addImageRef(ref string, owner string) error {
+	_, err := a.referenceStoredImage(ref, owner)
+	return err
+}
+
+// referenceStoredImage records owner as a reference to the stored image behind ref and
+// returns its entry, or nil when ref is not in the store
+func (a *App) referenceStoredImage(ref string, owner string) (*StoredImage, error) {
+	id := imageIDFromRef(ref)
+	if id == "" {
+		return nil, nil
+	}
+
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	index, err := a.loadImageIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	info, exists := index[id]
+	if !exists {
+		return nil, nil
+	}
+
+	info.Refs = addRef(info.Refs, owner)
+	if err := a.saveImageIndex(index); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// releaseImage drops owner's reference to the image behind ref and deletes the file
+// once nothing references it anymore. Local images outside the store are deleted directly.
+func (a *App) releaseImage(ref string, owner string) error {
+	if !isLocalImageRef(ref) {
+		return nil
+	}
+
+	a.imageMu.Lock()
+	defer a.imageMu.Unlock()
+
+	index, err := a.loadImageIndex()
+	if err != nil {
+		return err
+	}
+
+	id := imageIDFromRef(ref)
+	info, exists := index[id]
+	if !exists {
+		if id != "" {
+			// A store file the index doesn't know is left for RebuildImageIndex
+			return nil
+		}
+		a.removeThumbnails(ref)
+		return os.Remove(a.resolveImagePath(ref))
+	}
+
+	info.Refs = removeRef(info.Refs, owner)
+	if len(info.Refs) == 0 {
+		if err := os.Remove(a.resolveImagePath(info.Path)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		a.removeThumbnails(info.Path)
+		delete(index, info.ID)
+	}
+
+	return a.saveImageIndex(index)
+}
+
+// loadImageIndex reads images.json, returning an empty index if it doesn't exist.
+// A file that cannot be parsed is an errCorruptImageIndex error, never an empty index.
+func (a *App) loadImageIndex() (imageIndex, error) {
+	data, err := os.ReadFile(filepath.Join(a.dataRoot, imageIndexFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(imageIndex), nil
+		}
+		return nil, fmt.Errorf("failed to read image index: %w", err)
+	}
+
+	var index imageIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("%w, rebuild it to recover: %v", errCorruptImageIndex, err)
+	}
+	if index == nil {
+		index = make(imageIndex)
+	}
+	return index, nil
+}
+
+// updateImageRefs adds the stored image references in after that are missing from
+// before and releases those that are gone. Both map owners to image references.
+func (a *App) updateImageRefs(before, after map[string][]string) {
+	for owner, refs := range after {
+		for _, ref := range refs {
+			if !holdsImage(before[owner], ref) {
+				if err := a.addImageRef(ref, owner); err != nil {
+					fmt.Printf("Warning: Failed to reference image %s: %v\n", ref, err)
+				}
+			}
+		}
+	}
+	for owner, refs := range before {
+		for _, ref := range refs {
+			if !holdsImage(after[owner], ref) {
+				if err := a.releaseImage(ref, owner); err != nil {
+					fmt.Printf("Warning: Failed to release image %s: %v\n", ref, err)
+				}
+			}
+		}
+	}
+}
+
+// releaseImagesExcept drops owner's references to the stored images in refs that keep
+// does not hold as well
+func (a *App) releaseImagesExcept(owner string, refs, keep []string) {
+	for _, ref := range refs {
+		if imageIDFromRef(ref) == "" || holdsImage(keep, ref) {
+			continue
+		}
+		if err := a.releaseImage(ref, owner); err != nil {
+			fmt.Printf("Warning: Failed to release image %s: %v\n", ref, err)
+		}
+	}
+}
+
+// legacyImageFile returns the path of an image that older versions saved in the images
+// folder outside the store, or "" when ref is anything else
+func (a *App) legacyImageFile(ref string) string {
+	if !isLocalImageRef(ref) || imageIDFromRef(ref) != "" {
+		return ""
+	}
+	path := filepath.Clean(a.resolveImagePath(ref))
+	if filepath.Dir(path) != filepath.Clean(a.imagesDir()) {
+		return ""
+	}
+	return path
+}
+
+// removeAdoptedImageFiles deletes legacy image files that were copied into the store
+// once no history record or template references them anymore
+func (a *App) removeAdoptedImageFiles(paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		fmt.Printf("Warning: Failed to load history: %v\n", err)
+		return
+	}
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		fmt.Printf("Warning: Failed to load templates: %v\n", err)
+		return
+	}
+
+	referenced := a.referencedImagePaths(history, templates)
+	for _, path := range paths {
+		if len(referenced[path]) > 0 {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: Failed to remove adopted image %s: %v\n", path, err)
+			continue
+		}
+		a.removeThumbnails(path)
+	}
+}
+
+// templateImageRefs maps each template's owner to the stored images it references
+func templateImageRefs(templates []Template) map[string][]string {
+	refs := make(map[string][]string, len(templates))
+	for _, t := range templates {
+		owner := templateImageOwner(t.ID)
+		for _, ref := range append([]string{t.ImageURL}, t.ImageURLs...) {
+			if imageIDFromRef(ref) != "" && !holdsImage(refs[owner], ref) {
+				refs[owner] = append(refs[owner], ref)
+			}
+		}
+	}
+	return refs
+}
+
+// historyImageRefs maps each record's owner to the stored images it references
+func historyImageRefs(history []HistoryRecord) map[string][]string {
+	refs := make(map[string][]string, len(history))
+	for _, record := range history {
+		owner := historyImageOwner(record.ID)
+		for _, ref := range recordImageRefs(record) {
+			if imageIDFromRef(ref) != "" && !holdsImage(refs[owner], ref) {
+				refs[owner] = append(refs[owner], ref)
+			}
+		}
+	}
+	return refs
+}
+
+// recordImageRefs returns the generated and reference images of a record
+func recordImageRefs(record HistoryRecord) []string {
+	refs := make([]string, 0, len(record.Images)+len(record.Params.ReferenceImages))
+	for _, img := range record.Images {
+		refs = append(refs, img.URL)
+	}
+	return append(refs, record.Params.ReferenceImages...)
+}
+
+// holdsImage reports whether refs contains a reference to the same stored image as ref
+func holdsImage(refs []string, ref string) bool {
+	id := imageIDFromRef(ref)
+	for _, r := range refs {
+		if imageIDFromRef(r) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// saveImageIndex writes images.json
+func (a *App) saveImageIndex(index imageIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal image index: %w", err)
+	}
+	return writeFileAtomic(filepath.Join(a.dataRoot, imageIndexFile), data)
+}
+
+// historyImageOwner returns the image reference owner for a history record
+func historyImageOwner(recordID string) string {
+	return "history:" + recordID
+}
+
+// templateImageOwner returns the image reference owner for a template cover
+func templateImageOwner(templateID string) string {
+	return "template:" + templateID
+}
+
+// imageIDFromRef extracts the store ID from an image reference such as
+// "images/<sha256>.png", returning "" for references outside the store
+func imageIDFromRef(ref string) string {
+	if !isLocalImageRef(ref) {
+		return ""
+	}
+	name := filepath.Base(filepath.FromSlash(ref))
+	id := strings.TrimSuffix(name, filepath.Ext(name))
+	if len(id) != sha256.Size*2 {
+		return ""
+	}
+	if _, err := hex.DecodeString(id); err != nil {
+		return ""
+	}
+	return id
+}
+
+// detectImageFormat returns the format name and dimensions of encoded image data
+func detectImageFormat(data []byte) (string, int, int) {
+	if config, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		return format, config.Width, config.Height
+	}
+
+	// Fall back to content sniffing for formats without a registered decoder (e.g. WebP)
+	contentType := http.DetectContentType(data)
+	if strings.HasPrefix(contentType, "image/") {
+		return strings.TrimPrefix(contentType, "image/"), 0, 0
+	}
+	return "", 0, 0
+}
+
+// imageExtension returns the file extension for an image format name
+func imageExtension(format string) string {
+	switch format {
+	case "jpeg":
+		return ".jpg"
+	case "gif":
+		return ".gif"
+	case "webp":
+		return ".webp"
+	default:
+		return ".png"
+	}
+}
+
+// addRef appends owner to refs if it isn't already present
+func addRef(refs []string, owner string) []string {
+	if owner == "" {
+		return refs
+	}
+	for _, r := range refs {
+		if r == owner {
+			return refs
+		}
+	}
+	return append(refs, owner)
+}
+
+// removeRef removes owner from refs
+func removeRef(refs []string, owner string) []string {
+	filtered := refs[:0]
+	for _, r := range refs {
+		if r != owner {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,239 @@
+package backend
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Image Processing Helpers
+//
+// Pure standard library image operations shared by storage, thumbnails and
+// reference image preprocessing.
+
+// fitWithin scales width and height down to fit maxSize, keeping the aspect ratio.
+// Sizes that already fit, and a maxSize of zero, are returned unchanged.
+func fitWithin(width, height, maxSize int) (int, int) {
+	if maxSize <= 0 || (width <= maxSize && height <= maxSize) {
+		return width, height
+	}
+
+	scale := float64(maxSize) / float64(width)
+	if height > width {
+		scale = float64(maxSize) / float64(height)
+	}
+
+	w := int(math.Round(float64(width) * scale))
+	h := int(math.Round(float64(height) * scale))
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	return w, h
+}
+
+// toRGBA returns img as an RGBA image whose bounds start at the origin
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// resampleFilter is a separable resampling kernel with its support radius in source pixels
+type resampleFilter struct {
+	support float64
+	kernel  func(x float64) float64
+}
+
+// triangleFilter is a bilinear filter; fast and adequate for downscaling
+var triangleFilter = resampleFilter{support: 1, kernel: func(x float64) float64 {
+	return 1 - math.Abs(x)
+}}
+
+// lanczosFilter is a Lanczos-3 filter; sharper, for upscaling and high-quality resizes
+var lanczosFilter = resampleFilter{support: 3, kernel: func(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	if math.Abs(x) >= 3 {
+		return 0
+	}
+	px := math.Pi * x
+	return 3 * math.Sin(px) * math.Sin(px/3) / (px * px)
+}}
+
+// resampleImage scales img to width x height with a triangle (bilinear) filter whose
+// support widens when shrinking, so downscales average every source pixel
+func resampleImage(img image.Image, width, height int) *image.RGBA {
+	return resampleImageWith(img, width, height, triangleFilter)
+}
+
+// resampleImageWith scales img to width x height with the given filter
+func resampleImageWith(img image.Image, width, height int, filter resampleFilter) *image.RGBA {
+	src := toRGBA(img)
+	if src.Rect.Dx() == width && src.Rect.Dy() == height {
+		return src
+	}
+
+	horizontal := resampleAxis(src, width, src.Rect.Dy(), true, filter)
+	return resampleAxis(horizontal, width, height, false, filter)
+}
+
+// filterTaps are the source pixels and weights contributing to one output pixel
+type filterTaps struct {
+	start   int
+	weights []float64
+}
+
+// resampleWeights computes normalized filter taps for every output pixel
+func resampleWeights(dstSize, srcSize int, filter resampleFilter) []filterTaps {
+	scale := float64(srcSize) / float64(dstSize)
+	filterScale := math.Max(scale, 1)
+	support := filter.support * filterScale
+
+	taps := make([]filterTaps, dstSize)
+	for i := range taps {
+		center := (float64(i) + 0.5) * scale
+		start := int(math.Floor(center - support))
+		end := int(math.Ceil(center + support))
+		if start < 0 {
+			start = 0
+		}
+		if end > srcSize {
+			end = srcSize
+		}
+
+		weights := make([]float64, end-start)
+		var sum float64
+		for j := start; j < end; j++ {
+			w := filter.kernel((float64(j) + 0.5 - center) / filterScale)
+			if math.Abs((float64(j)+0.5-center)/support) < 1 {
+				weights[j-start] = w
+				sum += w
+			}
+		}
+
+		if sum == 0 {
+			// Degenerate case: fall back to the nearest source pixel
+			nearest := int(center)
+			if nearest >= srcSize {
+				nearest = srcSize - 1
+			}
+			taps[i] = filterTaps{start: nearest, weights: []float64{1}}
+			continue
+		}
+		for j := range weights {
+			weights[j] /= sum
+		}
+		taps[i] = filterTaps{start: start, weights: weights}
+	}
+	return taps
+}
+
+// resampleAxis scales src along one axis to width x height
+func resampleAxis(src *image.RGBA, width, height int, horizontal bool, filter resampleFilter) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	srcSize := src.Rect.Dy()
+	if horizontal {
+		srcSize = src.Rect.Dx()
+	}
+	dstSize := height
+	if horizontal {
+		dstSize = width
+	}
+	taps := resampleWeights(dstSize, srcSize, filter)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var t filterTaps
+			if horizontal {
+				t = taps[x]
+			} else {
+				t = taps[y]
+			}
+
+			var r, g, b, a float64
+			for k, w := range t.weights {
+				var offset int
+				if horizontal {
+					offset = y*src.Stride + (t.start+k)*4
+				} else {
+					offset = (t.start+k)*src.Stride + x*4
+				}
+				r += float64(src.Pix[offset]) * w
+				g += float64(src.Pix[offset+1]) * w
+				b += float64(src.Pix[offset+2]) * w
+				a += float64(src.Pix[offset+3]) * w
+			}
+
+			offset := y*dst.Stride + x*4
+			dst.Pix[offset] = clampByte(r)
+			dst.Pix[offset+1] = clampByte(g)
+			dst.Pix[offset+2] = clampByte(b)
+			dst.Pix[offset+3] = clampByte(a)
+		}
+	}
+	return dst
+}
+
+// clampByte rounds v to the nearest byte value
+func clampByte(v float64) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	default:
+		return uint8(v + 0.5)
+	}
+}
+
+// applyOrientation returns img transformed so an EXIF orientation of 2-8 displays
+// upright. Orientation 1 and unknown values return img unchanged.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+
+	// Orientations 5-8 swap width and height
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // Rotated 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // Mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // Mirrored along the top-left diagonal
+				dx, dy = y, x
+			case 6: // Rotated 90° clockwise
+				dx, dy = h-1-y, x
+			case 7: // Mirrored along the top-right diagonal
+				dx, dy = h-1-y, w-1-x
+			case 8: // Rotated 90° counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			copy(dst.Pix[dy*dst.Stride+dx*4:dy*dst.Stride+dx*4+4], src.Pix[y*src.Stride+x*4:y*src.Stride+x*4+4])
+		}
+	}
+	return dst
+}
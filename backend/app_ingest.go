@@ -0,0 +1,228 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Image Ingest Methods
+//
+// Every path that creates a history record goes through ingestRecord: each image is
+// fetched or decoded, validated, stored (converted to PNG with embedded generation
+// metadata), its dimensions and any metadata already embedded in it are extracted,
+// and finally one record holding all images is written to the history log.
+
+// maxSourceLength is how much of an image source is echoed back in ingest results
+const maxSourceLength = 80
+
+// IngestImages stores one or more images as a single history record
+func (a *App) IngestImages(req IngestRequest) (*IngestResult, error) {
+	record := HistoryRecord{
+		ID:        req.RecordID,
+		Params:    req.Params,
+		Images:    req.Images,
+		Timestamp: req.Timestamp,
+		Metadata:  req.Metadata,
+	}
+	return a.ingestRecord(record)
+}
+
+// Helper Methods
+
+// ingestRecord stores the images of record and appends it to the history log.
+// Image URLs and Params.ReferenceImages may be http(s) URLs, data URIs, local paths
+// or image store references; they are replaced by image store references. Images that
+// older versions saved in the images folder move into the store unchanged. Images that
+// fail are reported in the result; ingest fails only when none of them succeed.
+func (a *App) ingestRecord(record HistoryRecord) (*IngestResult, error) {
+	if record.ID == "" {
+		record.ID = fmt.Sprintf("record_%d", time.Now().UnixNano())
+	}
+	if record.Timestamp == 0 {
+		record.Timestamp = time.Now().Unix()
+	}
+	if record.Metadata == nil {
+		record.Metadata = make(map[string]interface{})
+	}
+	owner := historyImageOwner(record.ID)
+
+	// Writes of one ID are serialized, so each replacement releases exactly the images
+	// of the version it replaces
+	lock := a.recordLock(record.ID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	// A record with an existing ID replaces it. The images it used stay referenced until
+	// the new record is written; images referenced here are released again on failure.
+	previous, replacing, err := a.historyRecord(record.ID)
+	if err != nil {
+		return nil, err
+	}
+	var previousRefs []string
+	if replacing {
+		previousRefs = recordImageRefs(previous)
+	}
+	added := make([]string, 0, len(record.Images)+len(record.Params.ReferenceImages))
+	adopted := make([]string, 0)
+
+	result := &IngestResult{Images: make([]IngestedImage, 0, len(record.Images))}
+	stored := make([]GeneratedImage, 0, len(record.Images))
+	var firstErr error
+
+	for i, img := range record.Images {
+		ingested, err := a.ingestImage(img.URL, owner, &record.Params)
+		if err != nil {
+			ingested.Error = err.Error()
+			result.Failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			result.Images = append(result.Images, *ingested)
+			continue
+		}
+		result.Images = append(result.Images, *ingested)
+		added = append(added, ingested.Ref)
+		if path := a.legacyImageFile(img.URL); path != "" {
+			adopted = append(adopted, path)
+		}
+
+		id := img.ID
+		if id == "" {
+			id = fmt.Sprintf("%s_%d", record.ID, i)
+		}
+		stored = append(stored, GeneratedImage{
+			ID:     id,
+			URL:    ingested.Ref,
+			Width:  ingested.Width,
+			Height: ingested.Height,
+		})
+	}
+
+	if len(record.Images) > 0 && len(stored) == 0 {
+		return nil, fmt.Errorf("failed to ingest images: %w", firstErr)
+	}
+	record.Images = stored
+
+	// Keep reference images as image store references rather than inline base64
+	references := make([]string, 0, len(record.Params.ReferenceImages))
+	for _, src := range record.Params.ReferenceImages {
+		ref, err := a.storeReferenceImage(src, owner)
+		if err != nil {
+			fmt.Printf("Warning: Failed to store reference image: %v\n", err)
+			continue
+		}
+		references = append(references, ref)
+		added = append(added, ref)
+	}
+	record.Params.ReferenceImages = references
+
+	if err := a.appendHistoryLog(historyLogEntry{Op: historyOpPut, Record: &record}); err != nil {
+		a.releaseImagesExcept(owner, added, previousRefs)
+		return nil, err
+	}
+	a.search.put(historySearchDoc(record))
+	a.releaseImagesExcept(owner, previousRefs, recordImageRefs(record))
+	a.removeAdoptedImageFiles(adopted)
+
+	result.Record = &record
+	return result, nil
+}
+
+// ingestImage stores one image for owner. Images already in the store are referenced
+// as they are; anything else is fetched, validated and stored with the generation
+// metadata from params. Empty params fields are filled from metadata already embedded
+// in the source image. params.PostProcess records the pipeline GenerateImage already
+// applied; only its convert step matters here, deciding the stored format.
+func (a *App) ingestImage(src string, owner string, params *GenerationParams) (*IngestedImage, error) {
+	ingested := &IngestedImage{Source: describeImageSource(src)}
+	if src == "" {
+		return ingested, fmt.Errorf("image has no URL")
+	}
+
+	data, err := a.fetchImageData(src)
+	if err != nil {
+		return ingested, err
+	}
+
+	if chunks, err := readPNGTextChunks(data); err == nil && len(chunks) > 0 {
+		fillParamsFromMetadata(params, parseImageMetadata(chunks))
+	}
+
+	// Images already in the store are referenced under their existing ID
+	var info *StoredImage
+	if imageIDFromRef(src) != "" {
+		info, err = a.referenceStoredImage(src, owner)
+		if err != nil {
+			return ingested, err
+		}
+	}
+	switch {
+	case info != nil:
+	case imageIDFromRef(src) != "":
+		info, err = a.storeImage(data, owner)
+	case a.legacyImageFile(src) != "":
+		// Files saved by older versions keep their bytes, as RebuildImageIndex adopts them
+		info, err = a.storeImage(data, owner)
+	case params.PostProcess != nil && pipelineFormat(params.PostProcess) != "":
+		settings := a.storageSettings()
+		settings.Format = storageFormatOriginal
+		info, err = a.storeConvertedImageWith(data, owner, metadataFromParams(params), settings)
+	default:
+		info, err = a.storeConvertedImage(data, owner, metadataFromParams(params))
+	}
+	if err != nil {
+		return ingested, err
+	}
+
+	if info.Encoding != nil && !info.Encoding.Metadata {
+		ingested.Warning = metadataDroppedMessage(info.Format)
+	}
+
+	ingested.Ref = info.Path
+	ingested.Format = info.Format
+	ingested.Width = info.Width
+	ingested.Height = info.Height
+	ingested.Size = info.Size
+	return ingested, nil
+}
+
+// recordLock returns the lock serializing ingests of one history record ID
+func (a *App) recordLock(recordID string) *sync.Mutex {
+	lock, _ := a.recordLocks.LoadOrStore(recordID, &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// fillParamsFromMetadata copies embedded metadata into empty generation params
+func fillParamsFromMetadata(params *GenerationParams, meta *ImageMetadata) {
+	if params.Prompt == "" {
+		params.Prompt = meta.Prompt
+	}
+	if params.NegativePrompt == "" {
+		params.NegativePrompt = meta.NegativePrompt
+	}
+	if params.Provider == "" {
+		params.Provider = meta.Provider
+	}
+	if params.Model == "" {
+		params.Model = meta.Model
+	}
+	if params.Size == "" {
+		params.Size = meta.Size
+	}
+	if params.Seed == nil {
+		params.Seed = meta.Seed
+	}
+	if params.TemplateID == "" {
+		params.TemplateID = meta.TemplateID
+	}
+}
+
+// describeImageSource shortens data URIs so results don't echo whole images
+func describeImageSource(src string) string {
+	if strings.HasPrefix(src, "data:") && len(src) > maxSourceLength {
+		return src[:maxSourceLength] + "..."
+	}
+	return src
+}
@@ -0,0 +1,508 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Perceptual Hash Methods
+//
+// Every stored image gets an average, difference and DCT hash (aHash, dHash, pHash)
+// when it is stored; images stored before hashing existed are hashed on first use.
+// Similarity is the Hamming distance between pHashes: 0 for the same picture, up to
+// about 10 for re-encodes, resizes and small edits.
+
+const (
+	// defaultSimilarityThreshold is the pHash distance used when none is given
+	defaultSimilarityThreshold = 10
+	// maxSimilarityThreshold is the number of bits in a hash
+	maxSimilarityThreshold = 64
+
+	// pHashSize is the side of the grayscale image the DCT runs on
+	pHashSize = 32
+)
+
+// FindSimilarImages returns history images and template covers whose pHash is within
+// threshold bits of the given image, closest first. The image may be anything a
+// reference image accepts, and is left out of the results however it was given. A
+// threshold of 0 finds exact duplicates only; a negative threshold uses the default.
+func (a *App) FindSimilarImages(imageRef string, threshold int) ([]SimilarImage, error) {
+	threshold, err := similarityThreshold(threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := a.hashedImages()
+	if err != nil {
+		return nil, err
+	}
+
+	query, queryID, err := a.queryHash(imageRef)
+	if err != nil {
+		return nil, err
+	}
+
+	similar := make([]SimilarImage, 0)
+	for _, candidate := range candidates {
+		if candidate.Ref == imageRef || candidate.ImageID == imageRef ||
+			(queryID != "" && imageIDFromRef(candidate.Ref) == queryID) {
+			continue
+		}
+		distance := hammingDistance(query.p, candidate.hash.p)
+		if distance > threshold {
+			continue
+		}
+		match := candidate.SimilarImage
+		match.Distance = distance
+		similar = append(similar, match)
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].Distance < similar[j].Distance
+	})
+	return similar, nil
+}
+
+// GetDuplicateReport groups history images and template covers connected by pairs
+// within threshold bits of each other, so a group can chain beyond the threshold.
+// A threshold of 0 groups exact duplicates only; a negative threshold uses the default.
+func (a *App) GetDuplicateReport(threshold int) (*DuplicateReport, error) {
+	threshold, err := similarityThreshold(threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := a.hashedImages()
+	if err != nil {
+		return nil, err
+	}
+
+	// Oldest first, so the first image of every group is the original
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Timestamp < candidates[j].Timestamp
+	})
+
+	// Union-find over every pair within the threshold
+	parent := make([]int, len(candidates))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			if hammingDistance(candidates[i].hash.p, candidates[j].hash.p) <= threshold {
+				ri, rj := find(i), find(j)
+				if ri != rj {
+					// Keep the older image as the root
+					parent[max(ri, rj)] = min(ri, rj)
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range candidates {
+		root := find(i)
+		if _, exists := members[root]; !exists {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	report := &DuplicateReport{Threshold: threshold, Scanned: len(candidates), Groups: []DuplicateGroup{}}
+	for _, root := range roots {
+		indexes := members[root]
+		if len(indexes) < 2 {
+			continue
+		}
+
+		group := DuplicateGroup{Images: make([]SimilarImage, 0, len(indexes))}
+		for _, i := range indexes {
+			member := candidates[i].SimilarImage
+			member.Distance = hammingDistance(candidates[root].hash.p, candidates[i].hash.p)
+			group.Images = append(group.Images, member)
+		}
+		report.Groups = append(report.Groups, group)
+		report.Duplicates += len(indexes) - 1
+	}
+
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return len(report.Groups[i].Images) > len(report.Groups[j].Images)
+	})
+	return report, nil
+}
+
+// Helper Methods
+
+// imageHash is a decoded PerceptualHash
+type imageHash struct {
+	a, d, p uint64
+}
+
+// hashedImage is a search candidate with its hash
+type hashedImage struct {
+	SimilarImage
+	hash imageHash
+}
+
+// hashedImages returns every local history image and template cover with its hash.
+// Remote images are skipped so searches work offline.
+func (a *App) hashedImages() ([]hashedImage, error) {
+	stored, err := a.storedImageHashes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Local files outside the store are hashed once per search
+	files := make(map[string]*imageHash)
+	hashOf := func(ref string) *imageHash {
+		if id := imageIDFromRef(ref); id != "" {
+			if hash, ok := stored[id]; ok {
+				return hash
+			}
+		}
+		if hash, ok := files[ref]; ok {
+			return hash
+		}
+		hash, _ := hashImageFile(a.resolveImagePath(ref))
+		files[ref] = hash
+		return hash
+	}
+
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	var candidates []hashedImage
+	for _, record := range history {
+		for _, img := range record.Images {
+			if !isLocalImageRef(img.URL) {
+				continue
+			}
+			if hash := hashOf(img.URL); hash != nil {
+				candidates = append(candidates, hashedImage{
+					SimilarImage: SimilarImage{
+						Ref:       img.URL,
+						ImageID:   img.ID,
+						RecordID:  record.ID,
+						Timestamp: record.Timestamp,
+						Hash:      hash.encode(),
+					},
+					hash: *hash,
+				})
+			}
+		}
+	}
+	for _, t := range templates {
+		for _, ref := range append([]string{t.ImageURL}, t.ImageURLs...) {
+			if !isLocalImageRef(ref) {
+				continue
+			}
+			if hash := hashOf(ref); hash != nil {
+				candidates = append(candidates, hashedImage{
+					SimilarImage: SimilarImage{Ref: ref, TemplateID: t.ID, Hash: hash.encode()},
+					hash:         *hash,
+				})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// storedImageHashes returns the hash of every stored image keyed by image ID,
+// hashing and saving any that have none yet. Files that cannot be decoded are marked
+// so later searches skip them.
+func (a *App) storedImageHashes() (map[string]*imageHash, error) {
+	a.imageMu.Lock()
+	index, err := a.loadImageIndex()
+	a.imageMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make(map[string]*imageHash, len(index))
+	missing := make(map[string]*PerceptualHash)
+	for id, info := range index {
+		if info.HashFailed {
+			continue
+		}
+		if info.Hash != nil {
+			if hash, err := decodeImageHash(info.Hash); err == nil {
+				hashes[id] = hash
+				continue
+			}
+		}
+
+		// Decoding happens outside the lock; a nil hash records a failure
+		hash, err := hashImageFile(a.resolveImagePath(info.Path))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				missing[id] = nil
+			}
+			continue
+		}
+		hashes[id] = hash
+		missing[id] = hash.encode()
+	}
+
+	if len(missing) > 0 {
+		a.imageMu.Lock()
+		defer a.imageMu.Unlock()
+
+		index, err := a.loadImageIndex()
+		if err != nil {
+			return nil, err
+		}
+		for id, hash := range missing {
+			if info, exists := index[id]; exists {
+				info.Hash = hash
+				info.HashFailed = hash == nil
+			}
+		}
+		if err := a.saveImageIndex(index); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// queryHash returns the hash of a query image, using the stored hash when there is one,
+// and its image store ID when the image is stored
+func (a *App) queryHash(imageRef string) (*imageHash, string, error) {
+	resolved, err := a.resolveReferenceImage(imageRef)
+	if err != nil {
+		return nil, "", err
+	}
+
+	id := imageIDFromRef(resolved.storeRef)
+	if id != "" {
+		if info, err := a.GetImageInfo(id); err == nil && info.Hash != nil {
+			if hash, err := decodeImageHash(info.Hash); err == nil {
+				return hash, id, nil
+			}
+		}
+	}
+	hash, err := hashImageData(resolved.data)
+	if err != nil {
+		return nil, "", err
+	}
+	return hash, id, nil
+}
+
+// similarityThreshold validates a threshold, applying the default for negative values
+func similarityThreshold(threshold int) (int, error) {
+	if threshold < 0 {
+		return defaultSimilarityThreshold, nil
+	}
+	if threshold > maxSimilarityThreshold {
+		return 0, fmt.Errorf("threshold must be at most %d", maxSimilarityThreshold)
+	}
+	return threshold, nil
+}
+
+// hashImageFile hashes the image at path
+func hashImageFile(path string) (*imageHash, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return hashImageData(data)
+}
+
+// hashImageData decodes image data and hashes it
+func hashImageData(data []byte) (*imageHash, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	hash := computeImageHash(img)
+	return &hash, nil
+}
+
+// computeImageHash computes the aHash, dHash and pHash of img. The image is scaled
+// down once; the smaller aHash and dHash grids are averaged from the pHash grid.
+func computeImageHash(img image.Image) imageHash {
+	// Transparent areas count as white, as they are usually displayed
+	luma := grayscale(flattenImage(img), pHashSize, pHashSize)
+	return imageHash{
+		a: averageHash(shrinkLuma(luma, pHashSize, 8, 8)),
+		d: differenceHash(shrinkLuma(luma, pHashSize, 9, 8)),
+		p: dctHash(luma),
+	}
+}
+
+// grayscale scales img to width x height and returns its luma values row by row
+func grayscale(img image.Image, width, height int) []float64 {
+	small := resampleImage(img, width, height)
+	luma := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			o := y*small.Stride + x*4
+			luma[y*width+x] = 0.299*float64(small.Pix[o]) + 0.587*float64(small.Pix[o+1]) + 0.114*float64(small.Pix[o+2])
+		}
+	}
+	return luma
+}
+
+// shrinkLuma area-averages an n x n luma grid down to width x height
+func shrinkLuma(luma []float64, n, width, height int) []float64 {
+	// weights returns how much each source cell along one axis covers each target cell
+	weights := func(size int) [][]float64 {
+		scale := float64(n) / float64(size)
+		w := make([][]float64, size)
+		for t := range w {
+			w[t] = make([]float64, n)
+			start, end := float64(t)*scale, float64(t+1)*scale
+			for s := int(start); s < n && float64(s) < end; s++ {
+				w[t][s] = (math.Min(end, float64(s+1)) - math.Max(start, float64(s))) / scale
+			}
+		}
+		return w
+	}
+	wx, wy := weights(width), weights(height)
+
+	out := make([]float64, width*height)
+	for ty := 0; ty < height; ty++ {
+		for tx := 0; tx < width; tx++ {
+			var sum float64
+			for sy := 0; sy < n; sy++ {
+				if wy[ty][sy] == 0 {
+					continue
+				}
+				for sx := 0; sx < n; sx++ {
+					sum += luma[sy*n+sx] * wx[tx][sx] * wy[ty][sy]
+				}
+			}
+			out[ty*width+tx] = sum
+		}
+	}
+	return out
+}
+
+// averageHash sets a bit for every cell of an 8x8 grid brighter than the mean
+func averageHash(luma []float64) uint64 {
+	var mean float64
+	for _, v := range luma {
+		mean += v
+	}
+	mean /= float64(len(luma))
+
+	var hash uint64
+	for i, v := range luma {
+		if v > mean {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// differenceHash sets a bit for every cell of a 9x8 grid brighter than its right neighbor
+func differenceHash(luma []float64) uint64 {
+	var hash uint64
+	bit := 0
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if luma[y*9+x] > luma[y*9+x+1] {
+				hash |= 1 << uint(bit)
+			}
+			bit++
+		}
+	}
+	return hash
+}
+
+// dctHash sets a bit for every low-frequency DCT coefficient of a 32x32 thumbnail
+// above the median, leaving out the DC term
+func dctHash(luma []float64) uint64 {
+	const n = pHashSize
+
+	// Separable 2D DCT-II, keeping only the 8x8 lowest frequencies
+	cos := make([]float64, 8*n)
+	for u := 0; u < 8; u++ {
+		for x := 0; x < n; x++ {
+			cos[u*n+x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * n))
+		}
+	}
+
+	rows := make([]float64, n*8)
+	for y := 0; y < n; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < n; x++ {
+				sum += luma[y*n+x] * cos[u*n+x]
+			}
+			rows[y*8+u] = sum
+		}
+	}
+
+	coefficients := make([]float64, 64)
+	for v := 0; v < 8; v++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for y := 0; y < n; y++ {
+				sum += rows[y*8+u] * cos[v*n+y]
+			}
+			coefficients[v*8+u] = sum
+		}
+	}
+
+	sorted := append([]float64(nil), coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+
+	var hash uint64
+	for i, c := range coefficients {
+		if i > 0 && c > median {
+			hash |= 1 << uint(i)
+		}
+	}
+	return hash
+}
+
+// hammingDistance counts the differing bits of two hashes
+func hammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// encode formats a hash for the image index and API results
+func (h imageHash) encode() *PerceptualHash {
+	return &PerceptualHash{
+		AHash: fmt.Sprintf("%016x", h.a),
+		DHash: fmt.Sprintf("%016x", h.d),
+		PHash: fmt.Sprintf("%016x", h.p),
+	}
+}
+
+// decodeImageHash parses a hash from the image index
+func decodeImageHash(h *PerceptualHash) (*imageHash, error) {
+	var hash imageHash
+	var err error
+	if hash.a, err = strconv.ParseUint(h.AHash, 16, 64); err != nil {
+		return nil, err
+	}
+	if hash.d, err = strconv.ParseUint(h.DHash, 16, 64); err != nil {
+		return nil, err
+	}
+	if hash.p, err = strconv.ParseUint(h.PHash, 16, 64); err != nil {
+		return nil, err
+	}
+	return &hash, nil
+}
@@ -0,0 +1,395 @@
+package backend
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PNG Metadata Methods
+//
+// Generation metadata is embedded into saved PNGs as text chunks. The "parameters"
+// chunk follows the AUTOMATIC1111 convention (prompt, "Negative prompt:" line and a
+// final "Key: value, ..." line) so other tools can read it; the "spark-prompt" chunk
+// holds the same data as JSON for a lossless round trip.
+
+const (
+	pngParametersKeyword  = "parameters"
+	pngSparkPromptKeyword = "spark-prompt"
+
+	// maxInflatedText bounds a compressed text chunk once decompressed, so a crafted
+	// chunk can't expand into gigabytes
+	maxInflatedText = 1 << 20
+)
+
+// pngSignature is the 8-byte header of every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// ImportImageMetadata reads generation metadata from the text chunks of a PNG file
+func (a *App) ImportImageMetadata(path string) (*ImageMetadata, error) {
+	data, err := os.ReadFile(a.resolveImagePath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	chunks, err := readPNGTextChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseImageMetadata(chunks), nil
+}
+
+// ImportImageAsHistory stores an image in the image store and creates a history record
+// from its embedded generation metadata
+func (a *App) ImportImageAsHistory(path string) (*HistoryRecord, error) {
+	result, err := a.ingestRecord(HistoryRecord{
+		Images:   []GeneratedImage{{URL: path}},
+		Metadata: map[string]interface{}{"importedFrom": path},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Record, nil
+}
+
+// Helper Methods
+
+// metadataFromParams builds the metadata embedded for recorded generation params
+func metadataFromParams(params *GenerationParams) *ImageMetadata {
+	return &ImageMetadata{
+		Prompt:         params.Prompt,
+		NegativePrompt: params.NegativePrompt,
+		Provider:       params.Provider,
+		Model:          params.Model,
+		Size:           params.Size,
+		Seed:           params.Seed,
+		TemplateID:     params.TemplateID,
+	}
+}
+
+// embedPNGMetadata inserts the metadata text chunks into encoded PNG data right after IHDR
+func embedPNGMetadata(data []byte, meta *ImageMetadata) ([]byte, error) {
+	if meta == nil {
+		return data, nil
+	}
+	if !bytes.HasPrefix(data, pngSignature) || len(data) < len(pngSignature)+8 {
+		return nil, fmt.Errorf("not a PNG image")
+	}
+
+	// Replace rather than duplicate metadata already embedded in passed-through images
+	data = stripPNGTextChunks(data, pngParametersKeyword, pngSparkPromptKeyword)
+
+	// IHDR is always the first chunk
+	ihdrLength := int(binary.BigEndian.Uint32(data[len(pngSignature):]))
+	insertAt := len(pngSignature) + 12 + ihdrLength
+	if insertAt > len(data) {
+		return nil, fmt.Errorf("invalid PNG header")
+	}
+
+	jsonText, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal image metadata: %w", err)
+	}
+
+	var chunks bytes.Buffer
+	writePNGTextChunk(&chunks, pngParametersKeyword, formatA1111Parameters(meta))
+	writePNGTextChunk(&chunks, pngSparkPromptKeyword, string(jsonText))
+
+	result := make([]byte, 0, len(data)+chunks.Len())
+	result = append(result, data[:insertAt]...)
+	result = append(result, chunks.Bytes()...)
+	result = append(result, data[insertAt:]...)
+	return result, nil
+}
+
+// writePNGTextChunk writes a tEXt chunk, or an iTXt chunk when text isn't Latin-1
+func writePNGTextChunk(w *bytes.Buffer, keyword string, text string) {
+	var payload bytes.Buffer
+	payload.WriteString(keyword)
+	payload.WriteByte(0)
+
+	chunkType := "tEXt"
+	if isLatin1(text) {
+		for _, r := range text {
+			payload.WriteByte(byte(r))
+		}
+	} else {
+		// Uncompressed iTXt with empty language tag and translated keyword
+		chunkType = "iTXt"
+		payload.Write([]byte{0, 0, 0, 0})
+		payload.WriteString(text)
+	}
+
+	binary.Write(w, binary.BigEndian, uint32(payload.Len()))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(payload.Bytes())
+	w.WriteString(chunkType)
+	w.Write(payload.Bytes())
+	binary.Write(w, binary.BigEndian, crc.Sum32())
+}
+
+// stripPNGTextChunks removes the text chunks with the given keywords
+func stripPNGTextChunks(data []byte, keywords ...string) []byte {
+	result := make([]byte, 0, len(data))
+	result = append(result, data[:len(pngSignature)]...)
+
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			// Keep a truncated tail as it is
+			return append(result, data[pos:]...)
+		}
+
+		chunkType := string(data[pos+4 : pos+8])
+		drop := false
+		if chunkType == "tEXt" || chunkType == "zTXt" || chunkType == "iTXt" {
+			keyword, _, _ := bytes.Cut(data[pos+8:pos+8+length], []byte{0})
+			for _, k := range keywords {
+				if string(keyword) == k {
+					drop = true
+					break
+				}
+			}
+		}
+		if !drop {
+			result = append(result, data[pos:end]...)
+		}
+		pos = end
+	}
+	return append(result, data[pos:]...)
+}
+
+// readPNGTextChunks returns the text of every tEXt, zTXt and iTXt chunk keyed by keyword
+func readPNGTextChunks(data []byte) (map[string]string, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("not a PNG image")
+	}
+
+	chunks := make(map[string]string)
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		start := pos + 8
+		end := start + length
+		if length < 0 || end+4 > len(data) {
+			break
+		}
+		payload := data[start:end]
+		pos = end + 4
+
+		switch chunkType {
+		case "tEXt":
+			if keyword, text, ok := bytes.Cut(payload, []byte{0}); ok {
+				chunks[string(keyword)] = latin1ToString(text)
+			}
+		case "zTXt":
+			if keyword, rest, ok := bytes.Cut(payload, []byte{0}); ok && len(rest) > 0 {
+				if text, err := inflate(rest[1:]); err == nil {
+					chunks[string(keyword)] = latin1ToString(text)
+				}
+			}
+		case "iTXt":
+			if keyword, text, ok := parseITXt(payload); ok {
+				chunks[keyword] = text
+			}
+		case "IEND":
+			return chunks, nil
+		}
+	}
+
+	return chunks, nil
+}
+
+// parseITXt decodes an iTXt chunk payload
+func parseITXt(payload []byte) (string, string, bool) {
+	keyword, rest, ok := bytes.Cut(payload, []byte{0})
+	if !ok || len(rest) < 2 {
+		return "", "", false
+	}
+	compressed := rest[0] == 1
+	rest = rest[2:]
+
+	// Skip language tag and translated keyword
+	for i := 0; i < 2; i++ {
+		_, rest, ok = bytes.Cut(rest, []byte{0})
+		if !ok {
+			return "", "", false
+		}
+	}
+
+	if compressed {
+		text, err := inflate(rest)
+		if err != nil {
+			return "", "", false
+		}
+		return string(keyword), string(text), true
+	}
+	return string(keyword), string(rest), true
+}
+
+// parseImageMetadata builds metadata from PNG text chunks, preferring the lossless
+// spark-prompt chunk and falling back to the A1111 parameters chunk
+func parseImageMetadata(chunks map[string]string) *ImageMetadata {
+	meta := &ImageMetadata{}
+	if text, ok := chunks[pngSparkPromptKeyword]; ok {
+		json.Unmarshal([]byte(text), meta)
+	} else if text, ok := chunks[pngParametersKeyword]; ok {
+		meta = parseA1111Parameters(text)
+	}
+
+	meta.Parameters = chunks[pngParametersKeyword]
+	meta.Chunks = chunks
+	return meta
+}
+
+// formatA1111Parameters renders metadata in the AUTOMATIC1111 "parameters" format
+func formatA1111Parameters(meta *ImageMetadata) string {
+	var b strings.Builder
+	b.WriteString(meta.Prompt)
+	if meta.NegativePrompt != "" {
+		b.WriteString("\nNegative prompt: ")
+		b.WriteString(meta.NegativePrompt)
+	}
+
+	var fields []string
+	if meta.Seed != nil {
+		fields = append(fields, "Seed: "+strconv.FormatInt(*meta.Seed, 10))
+	}
+	if meta.Size != "" {
+		fields = append(fields, "Size: "+strings.ReplaceAll(meta.Size, "*", "x"))
+	}
+	if meta.Model != "" {
+		fields = append(fields, "Model: "+meta.Model)
+	}
+	if meta.Provider != "" {
+		fields = append(fields, "Provider: "+meta.Provider)
+	}
+	if meta.TemplateID != "" {
+		fields = append(fields, "Template: "+meta.TemplateID)
+	}
+	if len(fields) > 0 {
+		b.WriteString("\n")
+		b.WriteString(strings.Join(fields, ", "))
+	}
+
+	return b.String()
+}
+
+// parseA1111Parameters parses text in the AUTOMATIC1111 "parameters" format
+func parseA1111Parameters(text string) *ImageMetadata {
+	meta := &ImageMetadata{}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	// The last line holds "Key: value" pairs when it contains a known key
+	var fields map[string]string
+	if last := lines[len(lines)-1]; len(lines) > 1 || strings.Contains(last, "Seed: ") || strings.Contains(last, "Steps: ") {
+		if parsed := parseA1111Fields(last); parsed["Seed"] != "" || parsed["Steps"] != "" || parsed["Size"] != "" || parsed["Model"] != "" {
+			fields = parsed
+			lines = lines[:len(lines)-1]
+		}
+	}
+
+	var prompt, negative []string
+	inNegative := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "Negative prompt:") {
+			inNegative = true
+			line = strings.TrimSpace(strings.TrimPrefix(line, "Negative prompt:"))
+		}
+		if inNegative {
+			negative = append(negative, line)
+		} else {
+			prompt = append(prompt, line)
+		}
+	}
+
+	meta.Prompt = strings.TrimSpace(strings.Join(prompt, "\n"))
+	meta.NegativePrompt = strings.TrimSpace(strings.Join(negative, "\n"))
+
+	if seed, err := strconv.ParseInt(fields["Seed"], 10, 64); err == nil {
+		meta.Seed = &seed
+	}
+	meta.Size = fields["Size"]
+	meta.Model = fields["Model"]
+	meta.Provider = fields["Provider"]
+	meta.TemplateID = fields["Template"]
+	return meta
+}
+
+// parseA1111Fields splits a "Key: value, Key: value" line, honoring quoted values
+func parseA1111Fields(line string) map[string]string {
+	fields := make(map[string]string)
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case r == ',' && !inQuotes:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	parts = append(parts, current.String())
+
+	for _, part := range parts {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), ": ")
+		if ok {
+			fields[key] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	return fields
+}
+
+// isLatin1 reports whether text can be stored in a tEXt chunk
+func isLatin1(text string) bool {
+	for _, r := range text {
+		if r > 0xFF {
+			return false
+		}
+	}
+	return true
+}
+
+// latin1ToString decodes Latin-1 bytes
+func latin1ToString(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// inflate decompresses zlib data, refusing text that expands beyond maxInflatedText
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	text, err := io.ReadAll(io.LimitReader(r, maxInflatedText+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(text) > maxInflatedText {
+		return nil, fmt.Errorf("compressed text chunk expands beyond %d bytes", maxInflatedText)
+	}
+	return text, nil
+}
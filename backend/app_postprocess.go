@@ -0,0 +1,479 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Post-Processing Pipeline Methods
+//
+// A pipeline is a named list of steps (resize, crop, pad, watermark, convert) saved in
+// the configuration. A generation request names the pipeline to use; GenerateImage
+// runs its steps on every generated image before returning it, and the steps are
+// recorded in the history record so a regeneration applies them again unchanged.
+
+const (
+	postProcessResize    = "resize"
+	postProcessCrop      = "crop"
+	postProcessPad       = "pad"
+	postProcessWatermark = "watermark"
+	postProcessConvert   = "convert"
+
+	defaultWatermarkOpacity   = 0.5
+	defaultTextWatermarkSize  = 0.03
+	defaultImageWatermarkSize = 0.2
+	// watermarkMargin is the gap between a watermark and the image edge, as a fraction of the width
+	watermarkMargin = 0.02
+	// maxPostProcessDimension bounds resize and pad results
+	maxPostProcessDimension = 8192
+)
+
+// GetPostProcessPipelines returns all saved post-processing pipelines sorted by name
+func (a *App) GetPostProcessPipelines() ([]PostProcessPipeline, error) {
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	pipelines := make([]PostProcessPipeline, 0, len(config.PostProcessing))
+	for _, pipeline := range config.PostProcessing {
+		pipelines = append(pipelines, pipeline)
+	}
+	sort.Slice(pipelines, func(i, j int) bool {
+		return pipelines[i].Name < pipelines[j].Name
+	})
+	return pipelines, nil
+}
+
+// SavePostProcessPipeline creates or replaces the pipeline with the same name
+func (a *App) SavePostProcessPipeline(pipeline PostProcessPipeline) error {
+	pipeline.Name = strings.TrimSpace(pipeline.Name)
+	if err := validatePipeline(&pipeline); err != nil {
+		return err
+	}
+
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if config.PostProcessing == nil {
+		config.PostProcessing = make(map[string]PostProcessPipeline)
+	}
+	config.PostProcessing[pipeline.Name] = pipeline
+	config.UpdatedAt = time.Now()
+	return a.saveConfig(config)
+}
+
+// DeletePostProcessPipeline removes a saved pipeline
+func (a *App) DeletePostProcessPipeline(name string) error {
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if _, exists := config.PostProcessing[name]; !exists {
+		return fmt.Errorf("pipeline not found: %s", name)
+	}
+	delete(config.PostProcessing, name)
+	config.UpdatedAt = time.Now()
+	return a.saveConfig(config)
+}
+
+// PreviewPostProcess runs a pipeline on an image without storing anything and
+// returns the result as a data URI
+func (a *App) PreviewPostProcess(imageRef string, pipeline PostProcessPipeline) (string, error) {
+	if err := validatePipeline(&pipeline); err != nil {
+		return "", err
+	}
+
+	data, err := a.fetchImageData(imageRef)
+	if err != nil {
+		return "", err
+	}
+
+	out, format, err := a.applyPipeline(data, &pipeline)
+	if err != nil {
+		return "", err
+	}
+	if format == "" {
+		format = storageFormatPNG
+	}
+	return imageDataURI("image/"+format, out), nil
+}
+
+// Helper Methods
+
+// postProcessPipeline returns a copy of the saved pipeline with the given name
+func (a *App) postProcessPipeline(name string) (*PostProcessPipeline, error) {
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	pipeline, exists := config.PostProcessing[name]
+	if !exists {
+		return nil, fmt.Errorf("pipeline not found: %s", name)
+	}
+	pipeline.Steps = append([]PostProcessStep(nil), pipeline.Steps...)
+	return &pipeline, nil
+}
+
+// postProcessResponse runs pipeline on every image of a generation response, replacing
+// each with the processed image as a data URI
+func (a *App) postProcessResponse(resp *GenerateResponse, pipeline *PostProcessPipeline) error {
+	for i, img := range resp.Images {
+		data, err := a.fetchImageData(img.URL)
+		if err != nil {
+			return fmt.Errorf("failed to post-process image %d: %w", i+1, err)
+		}
+		out, format, err := a.applyPipeline(data, pipeline)
+		if err != nil {
+			return fmt.Errorf("failed to post-process image %d: %w", i+1, err)
+		}
+		if format == "" {
+			format = storageFormatPNG
+		}
+
+		_, width, height := detectImageFormat(out)
+		resp.Images[i].URL = imageDataURI("image/"+format, out)
+		resp.Images[i].Width = width
+		resp.Images[i].Height = height
+	}
+	return nil
+}
+
+// pipelineFormat returns the format the last convert step of pipeline converts to, or
+// "" when the pipeline has none
+func pipelineFormat(pipeline *PostProcessPipeline) string {
+	format := ""
+	for _, step := range pipeline.Steps {
+		if step.Type == postProcessConvert {
+			format = step.Format
+		}
+	}
+	return format
+}
+
+// applyPipeline runs every step of pipeline on image data. It returns the encoded
+// result and, when the pipeline has a convert step, the format it was converted
+// to; otherwise the result is lossless PNG and format is empty.
+func (a *App) applyPipeline(data []byte, pipeline *PostProcessPipeline) ([]byte, string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	format, quality := "", 0
+	for i, step := range pipeline.Steps {
+		switch step.Type {
+		case postProcessResize:
+			img, err = resizeStep(img, step)
+		case postProcessCrop:
+			img, err = cropStep(img, step)
+		case postProcessPad:
+			img, err = padStep(img, step)
+		case postProcessWatermark:
+			img, err = a.watermarkStep(img, step)
+		case postProcessConvert:
+			format, quality = step.Format, step.Quality
+		default:
+			err = fmt.Errorf("unknown step type: %s", step.Type)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("post-processing step %d (%s) failed: %w", i+1, step.Type, err)
+		}
+	}
+
+	target := format
+	if target == "" {
+		target = storageFormatPNG
+	}
+	if quality == 0 {
+		quality = defaultJPEGQuality
+	}
+
+	var buf bytes.Buffer
+	if err := imageEncoders[target](&buf, img, quality); err != nil {
+		return nil, "", fmt.Errorf("failed to encode image as %s: %w", target, err)
+	}
+	return buf.Bytes(), format, nil
+}
+
+// validatePipeline checks a pipeline and its steps before it is saved or run
+func validatePipeline(pipeline *PostProcessPipeline) error {
+	if pipeline.Name == "" {
+		return fmt.Errorf("pipeline name is required")
+	}
+
+	for i, step := range pipeline.Steps {
+		if err := validateStep(step); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i+1, step.Type, err)
+		}
+	}
+	return nil
+}
+
+// validateStep checks the options of one step
+func validateStep(step PostProcessStep) error {
+	switch step.Type {
+	case postProcessResize:
+		if step.Scale < 0 || step.Width < 0 || step.Height < 0 {
+			return fmt.Errorf("size and scale cannot be negative")
+		}
+		if step.Scale == 0 && step.Width == 0 && step.Height == 0 {
+			return fmt.Errorf("width, height or scale is required")
+		}
+	case postProcessCrop, postProcessPad:
+		if _, err := parseAspect(step.Aspect); err != nil {
+			return err
+		}
+		if _, err := parseColor(step.Color, color.White); err != nil {
+			return err
+		}
+	case postProcessWatermark:
+		if step.Text == "" && step.Image == "" {
+			return fmt.Errorf("text or image is required")
+		}
+		if !drawableText(step.Text) {
+			return fmt.Errorf("text has characters the built-in ASCII font can't draw; use an image watermark instead")
+		}
+		if step.Opacity < 0 || step.Opacity > 1 {
+			return fmt.Errorf("opacity must be between 0 and 1")
+		}
+		if step.Size < 0 || step.Size > 1 {
+			return fmt.Errorf("size must be between 0 and 1")
+		}
+		if _, ok := watermarkAnchors[step.Position]; !ok && step.Position != "" {
+			return fmt.Errorf("unknown position: %s", step.Position)
+		}
+		if _, err := parseColor(step.Color, color.White); err != nil {
+			return err
+		}
+	case postProcessConvert:
+		if _, ok := imageEncoders[step.Format]; !ok {
+			return fmt.Errorf("no encoder available for image format: %s", step.Format)
+		}
+		if step.Quality < 0 || step.Quality > 100 {
+			return fmt.Errorf("quality must be 0 (default) or 1-100")
+		}
+	default:
+		return fmt.Errorf("unknown step type")
+	}
+	return nil
+}
+
+// resizeStep scales an image with the Lanczos filter
+func resizeStep(img image.Image, step PostProcessStep) (image.Image, error) {
+	b := img.Bounds()
+	width, height := step.Width, step.Height
+	switch {
+	case step.Scale > 0:
+		width = int(math.Round(float64(b.Dx()) * step.Scale))
+		height = int(math.Round(float64(b.Dy()) * step.Scale))
+	case width == 0:
+		width = int(math.Round(float64(b.Dx()) * float64(height) / float64(b.Dy())))
+	case height == 0:
+		height = int(math.Round(float64(b.Dy()) * float64(width) / float64(b.Dx())))
+	}
+
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("resize result is empty")
+	}
+	if width > maxPostProcessDimension || height > maxPostProcessDimension {
+		return nil, fmt.Errorf("resize result %dx%d exceeds %d pixels", width, height, maxPostProcessDimension)
+	}
+	return resampleImageWith(img, width, height, lanczosFilter), nil
+}
+
+// cropStep crops the center of an image to the step's aspect ratio
+func cropStep(img image.Image, step PostProcessStep) (image.Image, error) {
+	aspect, err := parseAspect(step.Aspect)
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if float64(width)/float64(height) > aspect {
+		width = max(int(math.Round(float64(height)*aspect)), 1)
+	} else {
+		height = max(int(math.Round(float64(width)/aspect)), 1)
+	}
+
+	x := b.Min.X + (b.Dx()-width)/2
+	y := b.Min.Y + (b.Dy()-height)/2
+	cropped := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(cropped, cropped.Bounds(), img, image.Pt(x, y), draw.Src)
+	return cropped, nil
+}
+
+// padStep extends an image to the step's aspect ratio, centering it on the pad color
+func padStep(img image.Image, step PostProcessStep) (image.Image, error) {
+	aspect, err := parseAspect(step.Aspect)
+	if err != nil {
+		return nil, err
+	}
+	fill, err := parseColor(step.Color, color.White)
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if float64(width)/float64(height) > aspect {
+		height = int(math.Round(float64(width) / aspect))
+	} else {
+		width = int(math.Round(float64(height) * aspect))
+	}
+	if width > maxPostProcessDimension || height > maxPostProcessDimension {
+		return nil, fmt.Errorf("pad result %dx%d exceeds %d pixels", width, height, maxPostProcessDimension)
+	}
+
+	padded := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(padded, padded.Bounds(), image.NewUniform(fill), image.Point{}, draw.Src)
+	offset := image.Pt((width-b.Dx())/2, (height-b.Dy())/2)
+	draw.Draw(padded, b.Sub(b.Min).Add(offset), img, b.Min, draw.Over)
+	return padded, nil
+}
+
+// watermarkAnchors maps positions to horizontal and vertical alignment (0 start, 1 center, 2 end)
+var watermarkAnchors = map[string][2]int{
+	"top-left":     {0, 0},
+	"top":          {1, 0},
+	"top-right":    {2, 0},
+	"left":         {0, 1},
+	"center":       {1, 1},
+	"right":        {2, 1},
+	"bottom-left":  {0, 2},
+	"bottom":       {1, 2},
+	"bottom-right": {2, 2},
+}
+
+// watermarkStep draws a text or logo watermark over an image
+func (a *App) watermarkStep(img image.Image, step PostProcessStep) (image.Image, error) {
+	b := img.Bounds()
+
+	var mark image.Image
+	if step.Image != "" {
+		resolved, err := a.resolveReferenceImage(step.Image)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load watermark image: %w", err)
+		}
+		logo, _, err := image.Decode(bytes.NewReader(resolved.data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode watermark image: %w", err)
+		}
+
+		size := step.Size
+		if size == 0 {
+			size = defaultImageWatermarkSize
+		}
+		lb := logo.Bounds()
+		width := max(int(float64(b.Dx())*size), 1)
+		height := max(int(float64(width)*float64(lb.Dy())/float64(lb.Dx())), 1)
+		mark = resampleImageWith(logo, width, height, lanczosFilter)
+	} else {
+		textColor, err := parseColor(step.Color, color.White)
+		if err != nil {
+			return nil, err
+		}
+
+		size := step.Size
+		if size == 0 {
+			size = defaultTextWatermarkSize
+		}
+		// The font is scaled in whole pixels so glyph edges stay sharp
+		scale := max(int(float64(b.Dx())*size)/glyphHeight, 1)
+		mark = renderText(step.Text, scale, textColor)
+	}
+
+	opacity := step.Opacity
+	if opacity == 0 {
+		opacity = defaultWatermarkOpacity
+	}
+	position := step.Position
+	if position == "" {
+		position = "bottom-right"
+	}
+
+	anchor := watermarkAnchors[position]
+	margin := int(float64(b.Dx()) * watermarkMargin)
+	mb := mark.Bounds()
+	offsets := [2]int{}
+	for axis, free := range [2]int{b.Dx() - mb.Dx(), b.Dy() - mb.Dy()} {
+		switch anchor[axis] {
+		case 0:
+			offsets[axis] = margin
+		case 1:
+			offsets[axis] = free / 2
+		case 2:
+			offsets[axis] = free - margin
+		}
+	}
+
+	out := toRGBA(img)
+	if out == img {
+		// Don't draw over the caller's image
+		out = cloneRGBA(out)
+	}
+	target := mb.Sub(mb.Min).Add(image.Pt(offsets[0], offsets[1]))
+	mask := image.NewUniform(color.Alpha{A: uint8(math.Round(opacity * 255))})
+	draw.DrawMask(out, target, mark, mb.Min, mask, image.Point{}, draw.Over)
+	return out, nil
+}
+
+// cloneRGBA returns a copy of img
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	clone := *img
+	clone.Pix = append([]uint8(nil), img.Pix...)
+	return &clone
+}
+
+// parseAspect parses an aspect ratio such as "16:9" into width / height
+func parseAspect(aspect string) (float64, error) {
+	w, h, ok := strings.Cut(aspect, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid aspect ratio %q, expected W:H", aspect)
+	}
+	width, errW := strconv.ParseFloat(strings.TrimSpace(w), 64)
+	height, errH := strconv.ParseFloat(strings.TrimSpace(h), 64)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, fmt.Errorf("invalid aspect ratio %q, expected W:H", aspect)
+	}
+	return width / height, nil
+}
+
+// parseColor parses #RGB, #RRGGBB, #RRGGBBAA or "transparent", returning fallback when empty
+func parseColor(value string, fallback color.Color) (color.Color, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return fallback, nil
+	case strings.EqualFold(value, "transparent"):
+		return color.Transparent, nil
+	}
+
+	hex := strings.TrimPrefix(value, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || len(hex) != 8 {
+		return nil, fmt.Errorf("invalid color %q", value)
+	}
+
+	// Hex colors are not premultiplied, which NRGBA represents
+	return color.NRGBA{R: uint8(n >> 24), G: uint8(n >> 16), B: uint8(n >> 8), A: uint8(n)}, nil
+}
@@ -0,0 +1,394 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Reference Image Preprocessing
+//
+// Reference images are sent to providers inline, so before a request is built each
+// one is fitted to the model's limits: EXIF orientation is applied, the image is
+// downscaled to the pixel limit and re-encoded in an accepted format, dropping any
+// embedded metadata. Output that is still too large is re-encoded with stronger
+// compression and then downscaled further until it fits the byte limit.
+
+const (
+	// defaultMaxReferenceBytes applies when a model has no maxImageBytes
+	defaultMaxReferenceBytes = 7 << 20
+	// defaultMaxReferencePixels applies when a model has no maxImagePixels
+	defaultMaxReferencePixels = 2048 * 2048
+
+	referenceJPEGQuality    = 90
+	minReferenceJPEGQuality = 60
+	// minReferenceDimension is the smallest side images are shrunk to when fitting the byte limit
+	minReferenceDimension = 64
+)
+
+// defaultReferenceMimeTypes applies when a model lists no acceptedMimeTypes
+var defaultReferenceMimeTypes = []string{"image/png", "image/jpeg"}
+
+// resolvedImage is a reference image loaded from its source
+type resolvedImage struct {
+	data     []byte
+	mimeType string
+	storeRef string // Image store reference when the image is already stored
+}
+
+// ResolveReferenceImages resolves reference image sources without sending them
+// anywhere, reporting the type and size of each image or why it can't be used
+func (a *App) ResolveReferenceImages(images []string) ([]ReferenceImageInfo, error) {
+	infos := make([]ReferenceImageInfo, 0, len(images))
+	for _, src := range images {
+		info := ReferenceImageInfo{Source: describeImageSource(src)}
+
+		resolved, err := a.resolveReferenceImage(src)
+		if err != nil {
+			info.Error = err.Error()
+		} else {
+			info.MimeType = resolved.mimeType
+			info.Size = len(resolved.data)
+			if config, _, err := image.DecodeConfig(bytes.NewReader(resolved.data)); err == nil {
+				info.Width = config.Width
+				info.Height = config.Height
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// referenceImagesFor preprocesses each image to fit the model's capabilities. Images
+// the model can't take, because it has no capabilities entry or they exceed its
+// MaxReferenceImages, are refused rather than dropped. Every image that fails is
+// reported in the returned error.
+func (a *App) referenceImagesFor(provider *ProviderConfig, model string, images []string) ([]string, *APIError) {
+	if len(images) == 0 {
+		return nil, nil
+	}
+
+	caps, exists := provider.ModelCapabilities[model]
+	limit := 0
+	if exists {
+		limit = caps.MaxReferenceImages
+	}
+	if len(images) > limit {
+		dropped := make([]string, 0, len(images)-limit)
+		for i := limit; i < len(images); i++ {
+			dropped = append(dropped, fmt.Sprintf("reference image %d (%s)", i+1, describeImageSource(images[i])))
+		}
+		return nil, &APIError{
+			Code:     "REFERENCE_IMAGE_ERROR",
+			Message:  fmt.Sprintf("model %s accepts at most %d reference images, so these would be dropped: %s", model, limit, strings.Join(dropped, "; ")),
+			Provider: provider.ID,
+		}
+	}
+
+	prepared := make([]string, 0, len(images))
+	var failures []string
+	for i, src := range images {
+		resolved, err := a.resolveReferenceImage(src)
+		if err == nil {
+			var dataURI string
+			if dataURI, err = preprocessReferenceImage(resolved, caps); err == nil {
+				prepared = append(prepared, dataURI)
+				continue
+			}
+		}
+		failures = append(failures, fmt.Sprintf("reference image %d (%s): %v", i+1, describeImageSource(src), err))
+	}
+
+	if len(failures) > 0 {
+		return nil, &APIError{
+			Code:     "REFERENCE_IMAGE_ERROR",
+			Message:  strings.Join(failures, "; "),
+			Provider: provider.ID,
+		}
+	}
+	return prepared, nil
+}
+
+// Helper Methods
+
+// resolveReferenceImage loads a reference image from a data URI, http(s) URL, image
+// store ID, history image ID, or a path that is absolute or relative to the data root
+func (a *App) resolveReferenceImage(src string) (*resolvedImage, error) {
+	src = strings.TrimSpace(src)
+	switch {
+	case src == "":
+		return nil, fmt.Errorf("empty image source")
+
+	case strings.HasPrefix(src, "data:"):
+		header, payload, ok := strings.Cut(src, ",")
+		if !ok || !strings.HasSuffix(header, ";base64") {
+			return nil, fmt.Errorf("invalid data URI: expected base64 encoded data")
+		}
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 data: %w", err)
+		}
+		hint := strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+		return newResolvedImage(data, hint, "")
+
+	case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+		data, contentType, err := downloadImage(src)
+		if err != nil {
+			return nil, err
+		}
+		return newResolvedImage(data, contentType, "")
+	}
+
+	// A bare image store ID
+	if !strings.ContainsAny(src, `/\`) && imageIDFromRef(src+".png") != "" {
+		info, err := a.GetImageInfo(src)
+		if err != nil {
+			return nil, err
+		}
+		return a.readReferenceFile(info.Path)
+	}
+
+	path := a.resolveImagePath(src)
+	if _, err := os.Stat(path); os.IsNotExist(err) && !strings.ContainsAny(src, `/\.`) {
+		// Not a file: try the ID of an image in history
+		url, err := a.historyImageURL(src)
+		if err != nil {
+			return nil, err
+		}
+		if !isLocalImageRef(url) {
+			return a.resolveReferenceImage(url)
+		}
+		return a.readReferenceFile(url)
+	}
+	return a.readReferenceFile(src)
+}
+
+// readReferenceFile loads a reference image from a local path or image store reference
+func (a *App) readReferenceFile(ref string) (*resolvedImage, error) {
+	data, err := os.ReadFile(a.resolveImagePath(ref))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	hint, storeRef := "", ""
+	if isImageFileName(ref) {
+		hint = imageContentType(ref)
+	}
+	if imageIDFromRef(ref) != "" {
+		storeRef = ref
+	}
+	return newResolvedImage(data, hint, storeRef)
+}
+
+// historyImageURL returns the URL of the history image with the given ID
+func (a *App) historyImageURL(imageID string) (string, error) {
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return "", fmt.Errorf("failed to load history: %w", err)
+	}
+
+	for _, record := range history {
+		for _, img := range record.Images {
+			if img.ID == imageID {
+				return img.URL, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("image not found: %s", imageID)
+}
+
+// newResolvedImage sniffs the MIME type of data, falling back to hint for image types
+// that aren't detected, and rejects data that isn't an image
+func newResolvedImage(data []byte, hint, storeRef string) (*resolvedImage, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		hint, _, _ = strings.Cut(hint, ";")
+		hint = strings.ToLower(strings.TrimSpace(hint))
+		if !strings.HasPrefix(hint, "image/") {
+			return nil, fmt.Errorf("not an image (detected %s)", mimeType)
+		}
+		mimeType = hint
+	}
+	return &resolvedImage{data: data, mimeType: mimeType, storeRef: storeRef}, nil
+}
+
+// preprocessReferenceImage returns a resolved reference image as a data URI that
+// fits the limits in caps
+func preprocessReferenceImage(resolved *resolvedImage, caps ModelCapabilities) (string, error) {
+	data := resolved.data
+
+	maxBytes := caps.MaxImageBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxReferenceBytes
+	}
+	maxPixels := caps.MaxImagePixels
+	if maxPixels <= 0 {
+		maxPixels = defaultMaxReferencePixels
+	}
+	accepted := caps.AcceptedMimeTypes
+	if len(accepted) == 0 {
+		accepted = defaultReferenceMimeTypes
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		// Formats without a decoder (such as WebP) are sent unchanged if the model accepts them as they are
+		if errors.Is(err, image.ErrFormat) && acceptsMimeType(accepted, resolved.mimeType) && int64(len(data)) <= maxBytes {
+			return imageDataURI(resolved.mimeType, data), nil
+		}
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	b := img.Bounds()
+	if width, height := fitPixels(b.Dx(), b.Dy(), maxPixels); width != b.Dx() || height != b.Dy() {
+		img = resampleImage(img, width, height)
+	}
+
+	target := referenceFormat(format, accepted)
+	if target == "" {
+		return "", fmt.Errorf("model accepts none of the formats images can be converted to (%s)", strings.Join(accepted, ", "))
+	}
+
+	quality := referenceJPEGQuality
+	for {
+		var buf bytes.Buffer
+		if err := imageEncoders[target](&buf, img, quality); err != nil {
+			return "", fmt.Errorf("failed to encode image as %s: %w", target, err)
+		}
+		if int64(buf.Len()) <= maxBytes {
+			return imageDataURI("image/"+target, buf.Bytes()), nil
+		}
+
+		// Too large: switch to JPEG, then lower its quality, then shrink the image
+		b := img.Bounds()
+		switch {
+		case target != storageFormatJPEG && acceptsMimeType(accepted, "image/jpeg"):
+			target = storageFormatJPEG
+		case target == storageFormatJPEG && quality > minReferenceJPEGQuality:
+			quality -= 15
+		case b.Dx() > minReferenceDimension || b.Dy() > minReferenceDimension:
+			img = resampleImage(img, max(b.Dx()*3/4, 1), max(b.Dy()*3/4, 1))
+		default:
+			return "", fmt.Errorf("image cannot be reduced below %d bytes", maxBytes)
+		}
+	}
+}
+
+// referenceFormat picks the encoder for a reference image: the source format when the
+// model accepts it, otherwise PNG or JPEG. It returns "" when none is accepted.
+func referenceFormat(sourceFormat string, accepted []string) string {
+	for _, format := range []string{sourceFormat, storageFormatPNG, storageFormatJPEG} {
+		if _, ok := imageEncoders[format]; ok && acceptsMimeType(accepted, "image/"+format) {
+			return format
+		}
+	}
+	return ""
+}
+
+// acceptsMimeType reports whether mimeType is in accepted, ignoring case and parameters
+func acceptsMimeType(accepted []string, mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	for _, a := range accepted {
+		if strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(mimeType)) {
+			return true
+		}
+	}
+	return false
+}
+
+// fitPixels scales width and height down so their product fits maxPixels, keeping the aspect ratio
+func fitPixels(width, height int, maxPixels int64) (int, int) {
+	if maxPixels <= 0 || int64(width)*int64(height) <= maxPixels {
+		return width, height
+	}
+
+	scale := math.Sqrt(float64(maxPixels) / (float64(width) * float64(height)))
+	return max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+}
+
+// imageDataURI encodes data as a base64 data URI
+func imageDataURI(mimeType string, data []byte) string {
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data))
+}
+
+// jpegOrientation returns the EXIF orientation of JPEG data, or 1 when there is none
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == 0xFF {
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Image data starts; metadata segments come before it
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+
+		segment := data[pos+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		pos = end
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF-structured EXIF block
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		// Tag 0x0112 is Orientation, a SHORT stored inline in the value field
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,284 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// History Retention Methods
+
+// bytesPerGB converts the retention size limit to bytes
+const bytesPerGB = 1 << 30
+
+// GetRetentionPolicy returns the configured history retention policy
+func (a *App) GetRetentionPolicy() (*RetentionPolicy, error) {
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return &config.Retention, nil
+}
+
+// SetRetentionPolicy saves the history retention policy
+func (a *App) SetRetentionPolicy(policy RetentionPolicy) error {
+	if policy.KeepLast < 0 || policy.MaxAgeDays < 0 || policy.MaxTotalSizeGB < 0 {
+		return fmt.Errorf("retention limits cannot be negative")
+	}
+
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	config.Retention = policy
+	config.UpdatedAt = time.Now()
+	return a.saveConfig(config)
+}
+
+// ApplyRetention deletes the records the retention policy no longer keeps, together
+// with their images. Starred records are exempt. In dry-run mode nothing is deleted.
+func (a *App) ApplyRetention(dryRun bool) (*HistoryDeleteReport, error) {
+	policy, err := a.GetRetentionPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return a.deleteHistoryRecords(func(history []HistoryRecord, fp *imageFootprint) []HistoryRecord {
+		return selectRetentionVictims(history, policy, fp, now)
+	}, dryRun)
+}
+
+// DeleteHistoryByQuery deletes every record matching the query filters, together with
+// their images. Cursor and limit are ignored. In dry-run mode nothing is deleted.
+func (a *App) DeleteHistoryByQuery(query HistoryQuery, dryRun bool) (*HistoryDeleteReport, error) {
+	if isEmptyHistoryFilter(query) {
+		return nil, fmt.Errorf("a filter is required to delete history records")
+	}
+
+	return a.deleteHistoryRecords(func(history []HistoryRecord, fp *imageFootprint) []HistoryRecord {
+		var victims []HistoryRecord
+		for _, record := range history {
+			if matchHistoryQuery(record, query) {
+				victims = append(victims, record)
+			}
+		}
+		return victims
+	}, dryRun)
+}
+
+// Helper Methods
+
+// runRetentionAtStartup applies the retention policy when it is enabled for startup
+func (a *App) runRetentionAtStartup() {
+	policy, err := a.GetRetentionPolicy()
+	if err != nil || !policy.RunAtStartup {
+		return
+	}
+
+	report, err := a.ApplyRetention(false)
+	if err != nil {
+		fmt.Printf("Warning: Failed to apply retention policy: %v\n", err)
+		return
+	}
+	if report.Deleted > 0 {
+		fmt.Printf("Retention policy deleted %d history records (%d bytes)\n", report.Deleted, report.ReclaimedBytes)
+	}
+}
+
+// imageFootprint tracks image file sizes and how many owners reference each file
+type imageFootprint struct {
+	sizes map[string]int64
+	refs  map[string]int
+	paths map[string][]string // Record ID -> image files it references
+}
+
+// historyFootprint maps every history record to the image files it references and
+// counts references from history records and template covers
+func (a *App) historyFootprint(history []HistoryRecord) (*imageFootprint, error) {
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	fp := &imageFootprint{
+		sizes: make(map[string]int64),
+		refs:  make(map[string]int),
+		paths: make(map[string][]string),
+	}
+
+	addFile := func(ref string, seen map[string]bool) string {
+		if !isLocalImageRef(ref) {
+			return ""
+		}
+		path := filepath.Clean(a.resolveImagePath(ref))
+		if seen[path] {
+			return ""
+		}
+		seen[path] = true
+		fp.refs[path]++
+		if _, ok := fp.sizes[path]; !ok {
+			if info, err := os.Stat(path); err == nil {
+				fp.sizes[path] = info.Size()
+			} else {
+				fp.sizes[path] = 0
+			}
+		}
+		return path
+	}
+
+	for _, record := range history {
+		seen := make(map[string]bool)
+		refs := make([]string, 0, len(record.Images))
+		for _, img := range record.Images {
+			refs = append(refs, img.URL)
+		}
+		refs = append(refs, record.Params.ReferenceImages...)
+		for _, ref := range refs {
+			if path := addFile(ref, seen); path != "" {
+				fp.paths[record.ID] = append(fp.paths[record.ID], path)
+			}
+		}
+	}
+	for _, t := range templates {
+		seen := make(map[string]bool)
+		addFile(t.ImageURL, seen)
+		for _, url := range t.ImageURLs {
+			addFile(url, seen)
+		}
+	}
+
+	return fp, nil
+}
+
+// release drops a record's references and returns the bytes freed by files that
+// are no longer referenced
+func (fp *imageFootprint) release(recordID string) int64 {
+	var freed int64
+	for _, path := range fp.paths[recordID] {
+		fp.refs[path]--
+		if fp.refs[path] == 0 {
+			freed += fp.sizes[path]
+		}
+	}
+	return freed
+}
+
+// total returns the size of all referenced image files
+func (fp *imageFootprint) total() int64 {
+	var total int64
+	for path, refs := range fp.refs {
+		if refs > 0 {
+			total += fp.sizes[path]
+		}
+	}
+	return total
+}
+
+// deleteHistoryRecords deletes the records pick selects from history and releases their
+// images, or only reports what would be deleted in dry-run mode. Records are picked,
+// deleted and released under the history lock, so a record starred or replaced in
+// between is never deleted from a stale copy.
+func (a *App) deleteHistoryRecords(pick func(history []HistoryRecord, fp *imageFootprint) []HistoryRecord, dryRun bool) (*HistoryDeleteReport, error) {
+	a.historyMu.Lock()
+	defer a.historyMu.Unlock()
+
+	history, _, err := a.readHistoryLogLocked()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history: %w", err)
+	}
+	fp, err := a.historyFootprint(history)
+	if err != nil {
+		return nil, err
+	}
+
+	records := pick(history, fp)
+	report := &HistoryDeleteReport{DryRun: dryRun, RecordIDs: []string{}}
+	if len(records) == 0 {
+		return report, nil
+	}
+
+	entries := make([]historyLogEntry, 0, len(records))
+	for _, record := range records {
+		report.RecordIDs = append(report.RecordIDs, record.ID)
+		report.ReclaimedBytes += fp.release(record.ID)
+		entries = append(entries, historyLogEntry{Op: historyOpDelete, ID: record.ID})
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	// Images are released only once the records are gone, so a failed write keeps both
+	if err := a.appendHistoryLogLocked(entries...); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		a.search.remove(searchKindHistory, record.ID)
+		a.releaseHistoryImages(record)
+	}
+	report.Deleted = len(records)
+
+	return report, nil
+}
+
+// selectRetentionVictims returns the records the policy does not keep. The count and age
+// rules apply first; the size rule then removes the oldest remaining records until the
+// images fit.
+func selectRetentionVictims(history []HistoryRecord, policy *RetentionPolicy, fp *imageFootprint, now time.Time) []HistoryRecord {
+	sorted := append([]HistoryRecord{}, history...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return historyBefore(sorted[i], sorted[j], false)
+	})
+
+	cutoff := now.AddDate(0, 0, -policy.MaxAgeDays).Unix()
+	victims := make([]HistoryRecord, 0)
+	var remaining []HistoryRecord
+
+	// Starred records are always kept and don't count towards KeepLast
+	kept := 0
+	for _, record := range sorted {
+		switch {
+		case recordAnnotations(record).Starred:
+			continue
+		case policy.KeepLast > 0 && kept >= policy.KeepLast,
+			policy.MaxAgeDays > 0 && record.Timestamp < cutoff:
+			victims = append(victims, record)
+		default:
+			remaining = append(remaining, record)
+			kept++
+		}
+	}
+
+	if policy.MaxTotalSizeGB <= 0 {
+		return victims
+	}
+
+	// Work on a copy so the caller's footprint still reflects the current state
+	limit := int64(policy.MaxTotalSizeGB * bytesPerGB)
+	trial := &imageFootprint{sizes: fp.sizes, refs: make(map[string]int, len(fp.refs)), paths: fp.paths}
+	for path, refs := range fp.refs {
+		trial.refs[path] = refs
+	}
+	for _, record := range victims {
+		trial.release(record.ID)
+	}
+
+	total := trial.total()
+	for i := len(remaining) - 1; i >= 0 && total > limit; i-- {
+		total -= trial.release(remaining[i].ID)
+		victims = append(victims, remaining[i])
+	}
+
+	return victims
+}
+
+// isEmptyHistoryFilter reports whether a query has no filters and would match every record
+func isEmptyHistoryFilter(query HistoryQuery) bool {
+	return query.Provider == "" && query.Model == "" && query.Size == "" &&
+		query.TemplateID == "" && query.Prompt == "" && query.From == 0 && query.To == 0 &&
+		query.HasImages == nil && query.Starred == nil && query.MinRating == 0 && len(query.Labels) == 0
+}
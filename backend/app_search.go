@@ -0,0 +1,498 @@
+package backend
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Full-Text Search Methods
+//
+// The search index covers template names and content, bank labels and options, and
+// history prompts. It is built on the first search and then kept up to date by the
+// Ensure*/Delete* methods. Latin text is split into words; CJK text is indexed as
+// single characters plus overlapping bigrams so Chinese queries work without a dictionary.
+
+const (
+	searchKindTemplate = "template"
+	searchKindBank     = "bank"
+	searchKindHistory  = "history"
+
+	defaultSearchLimit = 50
+	snippetRadius      = 24 // Runes of context shown on each side of a highlight
+)
+
+// Search runs a ranked full-text query over templates, banks and history
+func (a *App) Search(query string, options SearchOptions) (*SearchResponse, error) {
+	if err := a.ensureSearchIndex(); err != nil {
+		return nil, err
+	}
+
+	limit := options.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	results, total := a.search.query(query, options.Kinds, limit)
+	return &SearchResponse{Results: results, Total: total}, nil
+}
+
+// RebuildSearchIndex discards the search index and rebuilds it from disk
+func (a *App) RebuildSearchIndex() error {
+	a.search.reset()
+	return a.ensureSearchIndex()
+}
+
+// Helper Methods
+
+// ensureSearchIndex builds the index from templates, banks and history if needed
+func (a *App) ensureSearchIndex() error {
+	if a.search.isBuilt() {
+		return nil
+	}
+
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+	banks, err := a.LoadBanks()
+	if err != nil {
+		return fmt.Errorf("failed to load banks: %w", err)
+	}
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return fmt.Errorf("failed to load history: %w", err)
+	}
+
+	docs := make([]*searchDoc, 0, len(templates)+len(banks)+len(history))
+	for _, t := range templates {
+		docs = append(docs, templateSearchDoc(t))
+	}
+	for key, bank := range banks {
+		docs = append(docs, bankSearchDoc(key, bank))
+	}
+	for _, record := range history {
+		docs = append(docs, historySearchDoc(record))
+	}
+
+	a.search.build(docs)
+	return nil
+}
+
+// templateSearchDoc converts a template into a search document
+func templateSearchDoc(t Template) *searchDoc {
+	doc := &searchDoc{Kind: searchKindTemplate, ID: t.ID, Title: localizedTitle(t.Name, t.ID)}
+	for _, lang := range sortedKeys(t.Name) {
+		doc.Fields = append(doc.Fields, searchField{Name: "name." + lang, Text: t.Name[lang], Weight: 3})
+	}
+	for _, lang := range sortedKeys(t.Content) {
+		doc.Fields = append(doc.Fields, searchField{Name: "content." + lang, Text: t.Content[lang], Weight: 1})
+	}
+	return doc
+}
+
+// bankSearchDoc converts a bank into a search document
+func bankSearchDoc(key string, bank BankItem) *searchDoc {
+	doc := &searchDoc{Kind: searchKindBank, ID: key, Title: localizedTitle(bank.Label, key)}
+	for _, lang := range sortedKeys(bank.Label) {
+		doc.Fields = append(doc.Fields, searchField{Name: "label." + lang, Text: bank.Label[lang], Weight: 3})
+	}
+
+	var options []string
+	for _, option := range bank.Options {
+		for _, lang := range sortedKeys(option) {
+			options = append(options, option[lang])
+		}
+	}
+	doc.Fields = append(doc.Fields, searchField{Name: "options", Text: strings.Join(options, " / "), Weight: 1})
+	return doc
+}
+
+// historySearchDoc converts a history record into a search document
+func historySearchDoc(record HistoryRecord) *searchDoc {
+	title := []rune(record.Params.Prompt)
+	if len(title) > 40 {
+		title = append(title[:40], '…')
+	}
+	return &searchDoc{
+		Kind:   searchKindHistory,
+		ID:     record.ID,
+		Title:  string(title),
+		Fields: []searchField{{Name: "prompt", Text: record.Params.Prompt, Weight: 1}},
+	}
+}
+
+// localizedTitle picks a display title from a localized map, preferring Chinese
+func localizedTitle(values map[string]string, fallback string) string {
+	for _, lang := range []string{"cn", "en"} {
+		if v := values[lang]; v != "" {
+			return v
+		}
+	}
+	for _, lang := range sortedKeys(values) {
+		if values[lang] != "" {
+			return values[lang]
+		}
+	}
+	return fallback
+}
+
+// sortedKeys returns the keys of a string map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// searchField is a piece of indexed text with a ranking weight
+type searchField struct {
+	Name   string
+	Text   string
+	Weight float64
+}
+
+// searchDoc is a document in the search index
+type searchDoc struct {
+	Kind   string
+	ID     string
+	Title  string
+	Fields []searchField
+	terms  map[string]float64 // Weighted term frequencies
+}
+
+// key returns the unique index key of the document
+func (d *searchDoc) key() string {
+	return d.Kind + ":" + d.ID
+}
+
+// searchIndex is an in-memory inverted index; the zero value is an empty, unbuilt index
+type searchIndex struct {
+	mu       sync.RWMutex
+	built    bool
+	docs     map[string]*searchDoc
+	postings map[string]map[string]bool // term -> document keys
+}
+
+// isBuilt reports whether the index has been populated
+func (s *searchIndex) isBuilt() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.built
+}
+
+// reset discards the index so it is rebuilt on the next search
+func (s *searchIndex) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.built = false
+	s.docs = nil
+	s.postings = nil
+}
+
+// build replaces the index contents with docs
+func (s *searchIndex) build(docs []*searchDoc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs = make(map[string]*searchDoc)
+	s.postings = make(map[string]map[string]bool)
+	for _, doc := range docs {
+		s.putLocked(doc)
+	}
+	s.built = true
+}
+
+// put adds or replaces a document; it is a no-op until the index is built
+func (s *searchIndex) put(doc *searchDoc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.built {
+		s.putLocked(doc)
+	}
+}
+
+// remove deletes a document; it is a no-op until the index is built
+func (s *searchIndex) remove(kind, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.built {
+		s.removeLocked(kind + ":" + id)
+	}
+}
+
+// replaceKind swaps every document of a kind for docs
+func (s *searchIndex) replaceKind(kind string, docs []*searchDoc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.built {
+		return
+	}
+	for key, doc := range s.docs {
+		if doc.Kind == kind {
+			s.removeLocked(key)
+		}
+	}
+	for _, doc := range docs {
+		s.putLocked(doc)
+	}
+}
+
+func (s *searchIndex) putLocked(doc *searchDoc) {
+	key := doc.key()
+	s.removeLocked(key)
+
+	doc.terms = make(map[string]float64)
+	for _, field := range doc.Fields {
+		for _, term := range tokenize(field.Text, true) {
+			doc.terms[term] += field.Weight
+		}
+	}
+
+	s.docs[key] = doc
+	for term := range doc.terms {
+		if s.postings[term] == nil {
+			s.postings[term] = make(map[string]bool)
+		}
+		s.postings[term][key] = true
+	}
+}
+
+func (s *searchIndex) removeLocked(key string) {
+	doc, exists := s.docs[key]
+	if !exists {
+		return
+	}
+	for term := range doc.terms {
+		delete(s.postings[term], key)
+		if len(s.postings[term]) == 0 {
+			delete(s.postings, term)
+		}
+	}
+	delete(s.docs, key)
+}
+
+// query returns ranked results for text, restricted to kinds when non-empty.
+// Every query term must match; Latin terms also match as word prefixes.
+func (s *searchIndex) query(text string, kinds []string, limit int) ([]SearchResult, int) {
+	terms := tokenize(text, false)
+	if len(terms) == 0 {
+		return []SearchResult{}, 0
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	allowed := make(map[string]bool)
+	for _, kind := range kinds {
+		allowed[kind] = true
+	}
+
+	total := float64(len(s.docs))
+	scores := make(map[string]float64)
+	for i, term := range terms {
+		matched := make(map[string]float64)
+		for _, indexTerm := range s.expandTerm(term) {
+			postings := s.postings[indexTerm]
+			idf := math.Log(1 + total/float64(len(postings)))
+			for key := range postings {
+				matched[key] += s.docs[key].terms[indexTerm] * idf
+			}
+		}
+
+		if i == 0 {
+			scores = matched
+			continue
+		}
+		for key := range scores {
+			if score, ok := matched[key]; ok {
+				scores[key] += score
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for key, score := range scores {
+		doc := s.docs[key]
+		if len(allowed) > 0 && !allowed[doc.Kind] {
+			continue
+		}
+		results = append(results, SearchResult{
+			Kind:       doc.Kind,
+			ID:         doc.ID,
+			Title:      doc.Title,
+			Score:      score,
+			Highlights: highlightFields(doc.Fields, text, terms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Kind+results[i].ID < results[j].Kind+results[j].ID
+	})
+
+	count := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, count
+}
+
+// expandTerm returns the index terms matching a query term: the term itself and,
+// for Latin words, every indexed word it prefixes
+func (s *searchIndex) expandTerm(term string) []string {
+	runes := []rune(term)
+	if isCJK(runes[0]) || len(runes) < 2 {
+		if _, ok := s.postings[term]; ok {
+			return []string{term}
+		}
+		return nil
+	}
+
+	var matches []string
+	for indexTerm := range s.postings {
+		if strings.HasPrefix(indexTerm, term) {
+			matches = append(matches, indexTerm)
+		}
+	}
+	return matches
+}
+
+// tokenize lowercases text and splits it into terms. Latin letters and digits form
+// words; CJK runs become bigrams. When indexing, single CJK characters are added
+// too so one-character queries can match.
+func tokenize(text string, indexing bool) []string {
+	var terms []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			terms = append(terms, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 || (indexing && len(cjk) > 0) {
+			for _, r := range cjk {
+				terms = append(terms, string(r))
+			}
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			terms = append(terms, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return terms
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
+
+// highlightFields builds snippets for the fields that contain the query, with matches
+// wrapped in <mark> tags. The surrounding text is HTML-escaped.
+func highlightFields(fields []searchField, query string, terms []string) []SearchHighlight {
+	// Prefer highlighting whole query words; fall back to the individual terms
+	needles := strings.Fields(strings.ToLower(query))
+	needles = append(needles, terms...)
+
+	highlights := make([]SearchHighlight, 0)
+	for _, field := range fields {
+		if snippet, ok := highlightText(field.Text, needles); ok {
+			highlights = append(highlights, SearchHighlight{Field: field.Name, Snippet: snippet})
+		}
+	}
+	return highlights
+}
+
+// highlightText returns a snippet of text around the first needle found
+func highlightText(text string, needles []string) (string, bool) {
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	if len(lower) != len(runes) {
+		// Lowercasing changed the length; highlight on the lowered text instead
+		runes = lower
+	}
+
+	// Mark every rune covered by a needle
+	marked := make([]bool, len(lower))
+	first := -1
+	for _, needle := range needles {
+		n := []rune(needle)
+		if len(n) == 0 {
+			continue
+		}
+		for i := 0; i+len(n) <= len(lower); i++ {
+			if string(lower[i:i+len(n)]) != needle {
+				continue
+			}
+			for j := i; j < i+len(n); j++ {
+				marked[j] = true
+			}
+			if first == -1 || i < first {
+				first = i
+			}
+		}
+	}
+	if first == -1 {
+		return "", false
+	}
+
+	start := first - snippetRadius
+	if start < 0 {
+		start = 0
+	}
+	end := first + snippetRadius*2
+	if end > len(runes) {
+		end = len(runes)
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for i := start; i < end; i++ {
+		if marked[i] && (i == start || !marked[i-1]) {
+			b.WriteString("<mark>")
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+		if marked[i] && (i == end-1 || !marked[i+1]) {
+			b.WriteString("</mark>")
+		}
+	}
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
@@ -0,0 +1,167 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Template Tag Methods
+
+// GetTagIndex returns every template tag with the number of templates using it,
+// most used first
+func (a *App) GetTagIndex() ([]TagCount, error) {
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, t := range templates {
+		for _, tag := range t.Tags {
+			counts[tag]++
+		}
+	}
+
+	index := make([]TagCount, 0, len(counts))
+	for tag, count := range counts {
+		index = append(index, TagCount{Tag: tag, Count: count})
+	}
+
+	sort.Slice(index, func(i, j int) bool {
+		if index[i].Count != index[j].Count {
+			return index[i].Count > index[j].Count
+		}
+		return index[i].Tag < index[j].Tag
+	})
+
+	return index, nil
+}
+
+// QueryTemplates returns templates matching the given tag, author and language filters
+func (a *App) QueryTemplates(query TemplateQuery) ([]Template, error) {
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := normalizeTags(query.Tags)
+	matchAny := strings.EqualFold(query.TagMode, "or")
+
+	result := make([]Template, 0)
+	for _, t := range templates {
+		if query.Author != "" && t.Author != query.Author {
+			continue
+		}
+		if !hasLanguages(t, query.Languages) {
+			continue
+		}
+		if len(wanted) > 0 && !matchTags(t.Tags, wanted, matchAny) {
+			continue
+		}
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+// RenameTag renames a tag across all templates, returning the number of templates changed.
+// Renaming to a tag a template already has merges the two.
+func (a *App) RenameTag(oldTag string, newTag string) (int, error) {
+	return a.MergeTags([]string{oldTag}, newTag)
+}
+
+// MergeTags replaces every source tag with target across all templates,
+// returning the number of templates changed
+func (a *App) MergeTags(sources []string, target string) (int, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return 0, fmt.Errorf("target tag is required")
+	}
+
+	sourceSet := make(map[string]bool)
+	for _, tag := range normalizeTags(sources) {
+		if tag != target {
+			sourceSet[tag] = true
+		}
+	}
+	if len(sourceSet) == 0 {
+		return 0, nil
+	}
+
+	templates, err := a.LoadTemplates()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for i, t := range templates {
+		replaced := false
+		tags := make([]string, 0, len(t.Tags))
+		for _, tag := range t.Tags {
+			if sourceSet[tag] {
+				tag = target
+				replaced = true
+			}
+			tags = append(tags, tag)
+		}
+		if replaced {
+			templates[i].Tags = normalizeTags(tags)
+			changed++
+		}
+	}
+
+	if changed == 0 {
+		return 0, nil
+	}
+
+	if err := a.SaveTemplates(templates); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+// Helper Methods
+
+// normalizeTags trims tags and removes empty and duplicate entries, keeping order
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// matchTags reports whether tags contain all (or any, if matchAny) of wanted
+func matchTags(tags []string, wanted []string, matchAny bool) bool {
+	has := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		has[tag] = true
+	}
+
+	for _, tag := range wanted {
+		if has[tag] && matchAny {
+			return true
+		}
+		if !has[tag] && !matchAny {
+			return false
+		}
+	}
+	return !matchAny
+}
+
+// hasLanguages reports whether a template has name and content in every language
+func hasLanguages(t Template, languages []string) bool {
+	for _, lang := range languages {
+		if strings.TrimSpace(t.Name[lang]) == "" || strings.TrimSpace(t.Content[lang]) == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -39,8 +39,28 @@ func (a *App) LoadTemplates() ([]Template, error) {
 	return templates, nil
 }
 
-// SaveTemplates saves templates to file system
+// SaveTemplates saves templates to file system. Stored cover images gained or dropped
+// by the save are referenced or released.
 func (a *App) SaveTemplates(templates []Template) error {
+	previous, err := a.LoadTemplates()
+	if err != nil {
+		return err
+	}
+	if err := a.writeTemplates(templates); err != nil {
+		return err
+	}
+	a.updateImageRefs(templateImageRefs(previous), templateImageRefs(templates))
+
+	docs := make([]*searchDoc, 0, len(templates))
+	for _, t := range templates {
+		docs = append(docs, templateSearchDoc(t))
+	}
+	a.search.replaceKind(searchKindTemplate, docs)
+	return nil
+}
+
+// writeTemplates writes templates.json without touching the search index
+func (a *App) writeTemplates(templates []Template) error {
 	data, err := json.MarshalIndent(templates, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal templates: %w", err)
@@ -55,9 +75,14 @@ func (a *App) EnsureTemplate(template Template) error {
 		return err
 	}
 
+	template.Tags = normalizeTags(template.Tags)
+
 	found := false
+	var previous []Template
 	for i, t := range templates {
 		if t.ID == template.ID {
+			template.Revision = t.Revision + 1
+			previous = append(previous, t)
 			templates[i] = template
 			found = true
 			break
@@ -65,16 +90,24 @@ func (a *App) EnsureTemplate(template Template) error {
 	}
 
 	if !found {
+		template.Revision = 1
 		templates = append(templates, template)
 	}
 
-	return a.SaveTemplates(templates)
+	if err := a.writeTemplates(templates); err != nil {
+		return err
+	}
+	a.updateImageRefs(templateImageRefs(previous), templateImageRefs([]Template{template}))
+
+	a.search.put(templateSearchDoc(template))
+	return nil
 }
 
 // SetTemplateCover sets the cover image for a template, ensuring the image is saved locally
 func (a *App) SetTemplateCover(templateID string, imageURL string) (string, error) {
 	// Persist image locally
-	localPath, err := a.persistImage(imageURL)
+	owner := templateImageOwner(templateID)
+	localPath, err := a.persistImage(imageURL, owner, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to persist cover image: %w", err)
 	}
@@ -94,9 +127,14 @@ func (a *App) SetTemplateCover(templateID string, imageURL string) (string, erro
 	}
 
 	if !found {
+		// Drop the reference persistImage added for the unknown template
+		if err := a.releaseImage(localPath, owner); err != nil {
+			fmt.Printf("Warning: Failed to release cover image %s: %v\n", localPath, err)
+		}
 		return "", fmt.Errorf("template not found: %s", templateID)
 	}
 
+	// Saving releases the replaced cover unless ImageURLs still uses it
 	if err := a.SaveTemplates(templates); err != nil {
 		return "", err
 	}
@@ -112,11 +150,23 @@ func (a *App) DeleteTemplate(id string) error {
 	}
 
 	var newTemplates []Template
+	var removed []Template
 	for _, t := range templates {
 		if t.ID != id {
 			newTemplates = append(newTemplates, t)
+		} else {
+			removed = append(removed, t)
 		}
 	}
 
-	return a.SaveTemplates(newTemplates)
+	if err := a.writeTemplates(newTemplates); err != nil {
+		return err
+	}
+
+	a.search.remove(searchKindTemplate, id)
+
+	// Drop the template's references to stored cover images
+	a.updateImageRefs(templateImageRefs(removed), nil)
+
+	return nil
 }
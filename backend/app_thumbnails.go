@@ -0,0 +1,217 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Thumbnail Methods
+//
+// Thumbnails are generated once per image and size and cached as JPEG files under
+// <data root>/thumbnails. All sizes of an image are made from a single decode, under
+// a lock held for that image only. A cached thumbnail is regenerated when its source
+// file is newer, and removed when the source image is deleted.
+
+const (
+	// thumbnailsDirName is the thumbnail cache folder under the data root
+	thumbnailsDirName = "thumbnails"
+	// thumbnailQuality is the JPEG quality of cached thumbnails
+	thumbnailQuality = 80
+)
+
+// thumbnailSizes are the generated thumbnail sizes (longest side in pixels)
+var thumbnailSizes = []int{128, 256, 512}
+
+// GetThumbnail returns a thumbnail of a stored or local image as a data URI. The
+// size is rounded up to the nearest generated size.
+func (a *App) GetThumbnail(imageRef string, size int) (string, error) {
+	path, err := a.ensureThumbnail(imageRef, size)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read thumbnail: %w", err)
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// WarmUpThumbnails generates missing thumbnails for every history image in the
+// background. Calls while a warm-up is running are ignored.
+func (a *App) WarmUpThumbnails() {
+	if !a.thumbWarming.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer a.thumbWarming.Store(false)
+
+		generated, err := a.warmUpThumbnails()
+		if err != nil {
+			fmt.Printf("Warning: Failed to warm up thumbnails: %v\n", err)
+			return
+		}
+		if generated > 0 {
+			fmt.Printf("Generated %d thumbnails\n", generated)
+		}
+	}()
+}
+
+// Helper Methods
+
+// warmUpThumbnails generates every size for every history image and returns how
+// many thumbnails were created
+func (a *App) warmUpThumbnails() (int, error) {
+	history, err := a.LoadAIHistory()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load history: %w", err)
+	}
+
+	generated := 0
+	for _, record := range history {
+		for _, img := range record.Images {
+			if !isLocalImageRef(img.URL) {
+				continue
+			}
+			// A data root switch waits for the current image
+			a.rootMu.RLock()
+			count, err := a.ensureThumbnails(img.URL)
+			a.rootMu.RUnlock()
+			if err != nil {
+				fmt.Printf("Warning: Failed to create thumbnail for %s: %v\n", img.URL, err)
+			}
+			generated += count
+		}
+	}
+	return generated, nil
+}
+
+// ensureThumbnail returns the path of an up-to-date cached thumbnail, generating it if needed
+func (a *App) ensureThumbnail(imageRef string, size int) (string, error) {
+	if !isLocalImageRef(imageRef) {
+		return "", fmt.Errorf("thumbnails are only available for local images")
+	}
+	size = snapThumbnailSize(size)
+	thumbPath := a.thumbnailPath(imageRef, size)
+
+	if a.thumbnailFresh(imageRef, size) {
+		return thumbPath, nil
+	}
+	if _, err := a.ensureThumbnails(imageRef); err != nil {
+		return "", err
+	}
+	return thumbPath, nil
+}
+
+// ensureThumbnails regenerates the missing or outdated sizes of an image from one
+// decode of the source and returns how many thumbnails were written
+func (a *App) ensureThumbnails(imageRef string) (int, error) {
+	lock := a.thumbnailLock(imageRef)
+	lock.Lock()
+	defer lock.Unlock()
+
+	stale := make([]int, 0, len(thumbnailSizes))
+	for _, size := range thumbnailSizes {
+		if !a.thumbnailFresh(imageRef, size) {
+			stale = append(stale, size)
+		}
+	}
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	file, err := os.Open(a.resolveImagePath(imageRef))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open image: %w", err)
+	}
+	img, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(a.dataRoot, thumbnailsDirName), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create thumbnails directory: %w", err)
+	}
+
+	// Largest first, so each smaller size is resampled from the previous one
+	b := img.Bounds()
+	generated := 0
+	for i := len(stale) - 1; i >= 0; i-- {
+		width, height := fitWithin(b.Dx(), b.Dy(), stale[i])
+		img = resampleImage(img, width, height)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, flattenImage(img), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+			return generated, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+		if err := writeFileAtomic(a.thumbnailPath(imageRef, stale[i]), buf.Bytes()); err != nil {
+			return generated, fmt.Errorf("failed to write thumbnail: %w", err)
+		}
+		generated++
+	}
+
+	return generated, nil
+}
+
+// thumbnailLock returns the lock serializing thumbnail generation for one image
+func (a *App) thumbnailLock(imageRef string) *sync.Mutex {
+	lock, _ := a.thumbLocks.LoadOrStore(a.thumbnailKey(imageRef), &sync.Mutex{})
+	return lock.(*sync.Mutex)
+}
+
+// thumbnailFresh reports whether a cached thumbnail exists and is not older than its source
+func (a *App) thumbnailFresh(imageRef string, size int) bool {
+	thumb, err := os.Stat(a.thumbnailPath(imageRef, size))
+	if err != nil {
+		return false
+	}
+	source, err := os.Stat(a.resolveImagePath(imageRef))
+	if err != nil {
+		return false
+	}
+	return !source.ModTime().After(thumb.ModTime())
+}
+
+// thumbnailPath returns the cache file of a thumbnail
+func (a *App) thumbnailPath(imageRef string, size int) string {
+	return filepath.Join(a.dataRoot, thumbnailsDirName, fmt.Sprintf("%s_%d.jpg", a.thumbnailKey(imageRef), size))
+}
+
+// thumbnailKey identifies an image in the thumbnail cache. Stored images are keyed by
+// their ID, other local files by a hash of their absolute path.
+func (a *App) thumbnailKey(imageRef string) string {
+	if key := imageIDFromRef(imageRef); key != "" {
+		return key
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(a.resolveImagePath(imageRef))))
+	return hex.EncodeToString(sum[:])
+}
+
+// removeThumbnails deletes every cached thumbnail of an image
+func (a *App) removeThumbnails(imageRef string) {
+	for _, size := range thumbnailSizes {
+		if err := os.Remove(a.thumbnailPath(imageRef, size)); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: Failed to remove thumbnail: %v\n", err)
+		}
+	}
+}
+
+// snapThumbnailSize rounds size up to the nearest generated thumbnail size
+func snapThumbnailSize(size int) int {
+	for _, s := range thumbnailSizes {
+		if size <= s {
+			return s
+		}
+	}
+	return thumbnailSizes[len(thumbnailSizes)-1]
+}
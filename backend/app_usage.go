@@ -0,0 +1,447 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage and Cost Accounting Methods
+//
+// Every successful generation is appended to usage.jsonl with its image and token
+// counts and a cost estimated from the configured price table, falling back to the
+// prices shipped in the embedded configuration. Before a request is sent its estimated
+// cost is held against the budgets until the generation is recorded, so concurrent
+// generations cannot overshoot a budget together.
+
+const (
+	// usageLedgerFile is the append-only usage ledger under the data root
+	usageLedgerFile = "usage.jsonl"
+	// defaultCurrency is used when no currency is configured
+	defaultCurrency = "CNY"
+)
+
+// GetUsageSettings returns the price table, currency and budgets
+func (a *App) GetUsageSettings() (*UsageSettings, error) {
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	settings := config.Usage
+	if settings.Currency == "" {
+		settings.Currency = defaultCurrency
+	}
+	settings.Prices = withDefaultPrices(&settings)
+	if settings.Budgets == nil {
+		settings.Budgets = []UsageBudget{}
+	}
+	return &settings, nil
+}
+
+// SetUsageSettings replaces the price table, currency and budgets
+func (a *App) SetUsageSettings(settings UsageSettings) error {
+	for _, budget := range settings.Budgets {
+		if budget.Period != "day" && budget.Period != "month" {
+			return fmt.Errorf("invalid budget period: %s", budget.Period)
+		}
+		if budget.Limit <= 0 {
+			return fmt.Errorf("budget limit must be positive")
+		}
+	}
+
+	config, err := a.loadOrCreateConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	settings.Currency = strings.ToUpper(strings.TrimSpace(settings.Currency))
+	settings.User = strings.TrimSpace(settings.User)
+	config.Usage = settings
+	config.UpdatedAt = time.Now()
+
+	return a.saveConfig(config)
+}
+
+// GetUsageEntries returns the ledger entries matching the query, newest first
+func (a *App) GetUsageEntries(query UsageQuery) ([]UsageEntry, error) {
+	entries, err := a.readUsageLedger()
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]UsageEntry, 0)
+	for _, entry := range entries {
+		if matchUsageQuery(entry, query) {
+			matched = append(matched, entry)
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Timestamp > matched[j].Timestamp
+	})
+	return matched, nil
+}
+
+// GetUsageSummary aggregates ledger entries per day, provider, model or user and
+// reports the state of every budget
+func (a *App) GetUsageSummary(query UsageQuery) (*UsageSummary, error) {
+	settings, err := a.GetUsageSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := a.readUsageLedger()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &UsageSummary{
+		Currency: settings.Currency,
+		Groups:   []UsageGroup{},
+		Budgets:  budgetStatuses(settings, entries, time.Now()),
+	}
+
+	groups := make(map[string]*UsageGroup)
+	for _, entry := range entries {
+		if !matchUsageQuery(entry, query) {
+			continue
+		}
+
+		key := usageGroupKey(entry, query.GroupBy)
+		group, ok := groups[key]
+		if !ok {
+			group = &UsageGroup{Key: key}
+			groups[key] = group
+		}
+		group.Requests++
+		group.Images += entry.Images
+		group.InputTokens += entry.InputTokens
+		group.OutputTokens += entry.OutputTokens
+		group.Cost += entry.Cost
+
+		summary.Requests++
+		summary.Images += entry.Images
+		summary.Cost += entry.Cost
+	}
+
+	for _, group := range groups {
+		summary.Groups = append(summary.Groups, *group)
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool {
+		return summary.Groups[i].Key < summary.Groups[j].Key
+	})
+
+	return summary, nil
+}
+
+// Helper Methods
+
+// reserveBudget holds the estimated cost of a request against the budgets covering the
+// provider, or returns a BUDGET_EXCEEDED error when the request would overshoot one.
+// The hold lasts until recordUsage or releaseBudget.
+func (a *App) reserveBudget(settings *UsageSettings, providerID string, model string, images int) (*UsageEntry, *APIError) {
+	hold := &UsageEntry{
+		Timestamp: time.Now().Unix(),
+		Provider:  providerID,
+		Model:     model,
+		Images:    images,
+	}
+	hold.Cost = estimateCost(settings, providerID, model, &GenerationUsage{Images: images})
+
+	a.budgetMu.Lock()
+	defer a.budgetMu.Unlock()
+
+	if len(settings.Budgets) > 0 {
+		entries, err := a.readUsageLedger()
+		if err != nil {
+			fmt.Printf("Warning: Failed to read usage ledger: %v\n", err)
+			entries = []UsageEntry{}
+		}
+		for held := range a.budgetHolds {
+			entries = append(entries, *held)
+		}
+
+		for _, status := range budgetStatuses(settings, entries, time.Now()) {
+			if status.Budget.Provider != "" && status.Budget.Provider != providerID {
+				continue
+			}
+
+			scope := "all providers"
+			if status.Budget.Provider != "" {
+				scope = status.Budget.Provider
+			}
+			if status.Exceeded {
+				return nil, &APIError{
+					Code:     "BUDGET_EXCEEDED",
+					Message:  fmt.Sprintf("The %s budget for %s is used up (%.2f of %.2f %s)", status.Budget.Period, scope, status.Spent, status.Budget.Limit, currencyOf(settings)),
+					Provider: providerID,
+				}
+			}
+			if status.Spent+hold.Cost > status.Budget.Limit {
+				return nil, &APIError{
+					Code:     "BUDGET_EXCEEDED",
+					Message:  fmt.Sprintf("The %s budget for %s has %.2f %s left, not enough for this request (about %.2f)", status.Budget.Period, scope, status.Remaining, currencyOf(settings), hold.Cost),
+					Provider: providerID,
+				}
+			}
+		}
+	}
+
+	if a.budgetHolds == nil {
+		a.budgetHolds = make(map[*UsageEntry]bool)
+	}
+	a.budgetHolds[hold] = true
+	return hold, nil
+}
+
+// releaseBudget drops a hold taken by reserveBudget; releasing it twice is harmless
+func (a *App) releaseBudget(hold *UsageEntry) {
+	a.budgetMu.Lock()
+	defer a.budgetMu.Unlock()
+	delete(a.budgetHolds, hold)
+}
+
+// recordUsage estimates the cost of a successful generation and appends it to the
+// ledger in place of the request's budget hold
+func (a *App) recordUsage(settings *UsageSettings, providerID string, model string, resp *GenerateResponse, hold *UsageEntry) {
+	usage := resp.Usage
+	if usage == nil {
+		usage = &GenerationUsage{Images: len(resp.Images)}
+		resp.Usage = usage
+	}
+	usage.Currency = currencyOf(settings)
+	usage.Cost = estimateCost(settings, providerID, model, usage)
+
+	entry := UsageEntry{
+		Timestamp:    time.Now().Unix(),
+		User:         usageUser(settings),
+		Provider:     providerID,
+		Model:        model,
+		Images:       usage.Images,
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		Cost:         usage.Cost,
+		Currency:     usage.Currency,
+		RequestID:    usage.RequestID,
+	}
+
+	a.budgetMu.Lock()
+	defer a.budgetMu.Unlock()
+
+	if err := a.appendUsageEntry(entry); err != nil {
+		fmt.Printf("Warning: Failed to record usage: %v\n", err)
+	}
+	delete(a.budgetHolds, hold)
+}
+
+// appendUsageEntry appends one entry to the usage ledger
+func (a *App) appendUsageEntry(entry UsageEntry) error {
+	return a.appendLedgerLine(a.usagePath, entry)
+}
+
+// readUsageLedger reads all ledger entries, skipping lines that fail to parse
+func (a *App) readUsageLedger() ([]UsageEntry, error) {
+	entries := make([]UsageEntry, 0)
+	err := a.readLedgerLines(a.usagePath, func(line []byte) {
+		var entry UsageEntry
+		if json.Unmarshal(line, &entry) == nil {
+			entries = append(entries, entry)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	return entries, nil
+}
+
+// appendLedgerLine appends v as one JSON line to an append-only ledger file
+func (a *App) appendLedgerLine(path string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ledger entry: %w", err)
+	}
+
+	a.ledgerMu.Lock()
+	defer a.ledgerMu.Unlock()
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger: %w", err)
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// readLedgerLines calls fn for every line of a ledger file; a missing file has no lines
+func (a *App) readLedgerLines(path string, fn func(line []byte)) error {
+	a.ledgerMu.Lock()
+	data, err := os.ReadFile(path)
+	a.ledgerMu.Unlock()
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		fn(scanner.Bytes())
+	}
+	return nil
+}
+
+// budgetStatuses computes spending for each budget within its current period
+func budgetStatuses(settings *UsageSettings, entries []UsageEntry, now time.Time) []BudgetStatus {
+	statuses := make([]BudgetStatus, 0, len(settings.Budgets))
+	for _, budget := range settings.Budgets {
+		start := budgetPeriodStart(budget.Period, now).Unix()
+
+		status := BudgetStatus{Budget: budget}
+		for _, entry := range entries {
+			if entry.Timestamp < start {
+				continue
+			}
+			if budget.Provider != "" && entry.Provider != budget.Provider {
+				continue
+			}
+			status.Spent += entry.Cost
+		}
+		status.Remaining = budget.Limit - status.Spent
+		if status.Remaining < 0 {
+			status.Remaining = 0
+		}
+		status.Exceeded = status.Spent >= budget.Limit
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// budgetPeriodStart returns the local start of the day or month containing now
+func budgetPeriodStart(period string, now time.Time) time.Time {
+	if period == "month" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	}
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// estimateCost prices a generation using the configured price table, falling back to
+// the default prices
+func estimateCost(settings *UsageSettings, providerID string, model string, usage *GenerationUsage) float64 {
+	price, ok := settings.Prices[providerID][model]
+	if !ok {
+		price, ok = withDefaultPrices(settings)[providerID][model]
+	}
+	if !ok {
+		return 0
+	}
+
+	return float64(usage.Images)*price.PerImage +
+		float64(usage.InputTokens)*price.PerMillionInputTokens/1e6 +
+		float64(usage.OutputTokens)*price.PerMillionOutputTokens/1e6
+}
+
+// matchUsageQuery reports whether an entry falls within the query filters
+func matchUsageQuery(entry UsageEntry, query UsageQuery) bool {
+	if query.From > 0 && entry.Timestamp < query.From {
+		return false
+	}
+	if query.To > 0 && entry.Timestamp >= query.To {
+		return false
+	}
+	if query.Provider != "" && entry.Provider != query.Provider {
+		return false
+	}
+	if query.User != "" && entry.User != query.User {
+		return false
+	}
+	return true
+}
+
+// usageGroupKey returns the aggregation key of an entry
+func usageGroupKey(entry UsageEntry, groupBy string) string {
+	switch groupBy {
+	case "provider":
+		return entry.Provider
+	case "model":
+		return entry.Provider + "/" + entry.Model
+	case "user":
+		return entry.User
+	default:
+		return time.Unix(entry.Timestamp, 0).Format("2006-01-02")
+	}
+}
+
+// usageUser returns the name recorded on ledger entries
+func usageUser(settings *UsageSettings) string {
+	if settings.User != "" {
+		return settings.User
+	}
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return ""
+}
+
+// currencyOf returns the configured currency or the default
+func currencyOf(settings *UsageSettings) string {
+	if settings.Currency != "" {
+		return settings.Currency
+	}
+	return defaultCurrency
+}
+
+var (
+	defaultUsageOnce sync.Once
+	defaultUsage     UsageSettings
+)
+
+// defaultUsageSettings returns the usage section of the embedded configuration
+func defaultUsageSettings() UsageSettings {
+	defaultUsageOnce.Do(func() {
+		data, err := defaultConfigFS.ReadFile("json/ai-providers.json")
+		if err != nil {
+			return
+		}
+		var config Configuration
+		if err := json.Unmarshal(data, &config); err == nil {
+			defaultUsage = config.Usage
+		}
+	})
+	return defaultUsage
+}
+
+// withDefaultPrices returns the configured price table completed with the default
+// prices of models it doesn't list. Defaults only apply in their own currency.
+func withDefaultPrices(settings *UsageSettings) map[string]map[string]ModelPrice {
+	prices := make(map[string]map[string]ModelPrice)
+	defaults := defaultUsageSettings()
+	if currencyOf(settings) == currencyOf(&defaults) {
+		for providerID, models := range defaults.Prices {
+			prices[providerID] = make(map[string]ModelPrice)
+			for model, price := range models {
+				prices[providerID][model] = price
+			}
+		}
+	}
+	for providerID, models := range settings.Prices {
+		if prices[providerID] == nil {
+			prices[providerID] = make(map[string]ModelPrice)
+		}
+		for model, price := range models {
+			prices[providerID][model] = price
+		}
+	}
+	return prices
+}
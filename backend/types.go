@@ -8,8 +8,23 @@ type GenerateRequest struct {
 	Provider   string         `json:"provider" binding:"required"`
 	Model      string         `json:"model"`
 	Size       string         `json:"size"`
-	Images     []string       `json:"images"` // Base64 encoded or local paths
+	Images     []string       `json:"images"` // Data URIs, local paths, http(s) URLs or image IDs
 	Parameters map[string]any `json:"parameters"`
+
+	// Optional generation controls
+	NegativePrompt string `json:"negativePrompt,omitempty"`
+	Seed           *int64 `json:"seed,omitempty"` // A random seed is assigned when empty
+
+	// Provenance recorded in history
+	TemplateID       string            `json:"templateId,omitempty"`
+	TemplateRevision int               `json:"templateRevision,omitempty"`
+	Language         string            `json:"language,omitempty"`
+	Selections       map[string]string `json:"selections,omitempty"` // Placeholder values keyed by placeholder
+
+	// Post-processing applied to the generated images: a saved pipeline by name, or the
+	// steps themselves (as replayed from history), which take precedence
+	Pipeline    string               `json:"pipeline,omitempty"`
+	PostProcess *PostProcessPipeline `json:"postProcess,omitempty"`
 }
 
 // GenerateResponse represents an image generation response
@@ -17,6 +32,18 @@ type GenerateResponse struct {
 	Success bool             `json:"success"`
 	Images  []GeneratedImage `json:"images,omitempty"`
 	Error   *APIError        `json:"error,omitempty"`
+	Seed    *int64           `json:"seed,omitempty"` // Seed the request was made with
+	Usage   *GenerationUsage `json:"usage,omitempty"`
+}
+
+// GenerationUsage is the usage reported by a provider for one generation
+type GenerationUsage struct {
+	Images       int     `json:"images"`
+	InputTokens  int     `json:"inputTokens,omitempty"`
+	OutputTokens int     `json:"outputTokens,omitempty"`
+	Cost         float64 `json:"cost"` // Estimated from the price table
+	Currency     string  `json:"currency"`
+	RequestID    string  `json:"requestId,omitempty"`
 }
 
 // GeneratedImage represents a generated image
@@ -98,9 +125,15 @@ type SaveFileRequest struct {
 
 // Configuration represents the application configuration
 type Configuration struct {
-	Providers      map[string]ProviderConfig `json:"providers"`
-	ActiveProvider string                    `json:"activeProvider"`
-	UpdatedAt      time.Time                 `json:"updatedAt"`
+	Providers      map[string]ProviderConfig      `json:"providers"`
+	ActiveProvider string                         `json:"activeProvider"`
+	Usage          UsageSettings                  `json:"usage"`
+	Retention      RetentionPolicy                `json:"retention"`
+	Storage        StorageSettings                `json:"storage"`
+	PostProcessing map[string]PostProcessPipeline `json:"postProcessing,omitempty"` // Keyed by pipeline name
+	UpdatedAt      time.Time                      `json:"updatedAt"`
+
+	MergedDefaults []string `json:"mergedDefaults,omitempty"` // Built-in providers, models and capability fields already merged in
 }
 
 // HistoryRecord represents an AI generation history record
@@ -109,7 +142,18 @@ type HistoryRecord struct {
 	Params    GenerationParams       `json:"params"`
 	Images    []GeneratedImage       `json:"images"`
 	Timestamp int64                  `json:"timestamp"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"` // User annotations live under "annotations"
+
+	SourceRecords []string `json:"sourceRecords,omitempty"` // Records a composite image was made from
+}
+
+// HistoryAnnotations are the user annotations of a history record, stored as
+// HistoryRecord.Metadata["annotations"]
+type HistoryAnnotations struct {
+	Starred bool     `json:"starred,omitempty"`
+	Rating  int      `json:"rating,omitempty"` // 1-5, 0 when unrated
+	Note    string   `json:"note,omitempty"`
+	Labels  []string `json:"labels,omitempty"`
 }
 
 // GenerationParams represents the parameters used for image generation
@@ -119,6 +163,17 @@ type GenerationParams struct {
 	Model      string                 `json:"model"`
 	Size       string                 `json:"size"`
 	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	TemplateID string                 `json:"templateId,omitempty"` // Template the prompt was built from
+
+	TemplateRevision int               `json:"templateRevision,omitempty"`
+	Language         string            `json:"language,omitempty"`
+	Selections       map[string]string `json:"selections,omitempty"` // Placeholder values keyed by placeholder
+	Seed             *int64            `json:"seed,omitempty"`
+	NegativePrompt   string            `json:"negativePrompt,omitempty"`
+	ReferenceImages  []string          `json:"referenceImages,omitempty"` // Image store references
+	RegeneratedFrom  string            `json:"regeneratedFrom,omitempty"` // Source record ID for regenerations
+
+	PostProcess *PostProcessPipeline `json:"postProcess,omitempty"` // Pipeline as applied to the images
 }
 
 // Template represents a prompt template
@@ -129,6 +184,8 @@ type Template struct {
 	ImageURL  string            `json:"imageUrl"`
 	ImageURLs []string          `json:"imageUrls,omitempty"`
 	Author    string            `json:"author"`
+	Tags      []string          `json:"tags,omitempty"`
+	Revision  int               `json:"revision,omitempty"` // Incremented on every update
 }
 
 // BankItem represents a category of words/phrases for substitution
@@ -151,6 +208,493 @@ type CategoryMap map[string]Category
 
 // ModelCapabilities defines what a model supports
 type ModelCapabilities struct {
-	SupportsReferenceImage bool `json:"supportsReferenceImage"`
-	MaxReferenceImages     int  `json:"maxReferenceImages"`
+	SupportsReferenceImage bool     `json:"supportsReferenceImage"`
+	RequiresReferenceImage bool     `json:"requiresReferenceImage,omitempty"` // Edit models that need an input image
+	MaxReferenceImages     int      `json:"maxReferenceImages"`
+	MaxImageBytes          int64    `json:"maxImageBytes,omitempty"`     // Largest encoded reference image
+	MaxImagePixels         int64    `json:"maxImagePixels,omitempty"`    // Largest reference image width * height
+	AcceptedMimeTypes      []string `json:"acceptedMimeTypes,omitempty"` // Reference image types the model accepts
+}
+
+// ReferenceImageInfo describes a reference image after resolving its source
+type ReferenceImageInfo struct {
+	Source   string `json:"source"` // Data URIs are truncated
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int    `json:"size,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Error    string `json:"error,omitempty"` // Why the image could not be resolved
+}
+
+// DataRootInfo describes where application data is stored
+type DataRootInfo struct {
+	Path      string `json:"path"`
+	ImagesDir string `json:"imagesDir"`
+	Portable  bool   `json:"portable"` // Data sits next to the executable
+	Custom    bool   `json:"custom"`   // Data root was chosen by the user
+
+	ImportedFrom string `json:"importedFrom,omitempty"` // Installed data root copied in on the first portable start
+}
+
+// ImageMigrationReport summarizes moving images into the data root
+type ImageMigrationReport struct {
+	Moved          int      `json:"moved"`
+	RewrittenPaths int      `json:"rewrittenPaths"`
+	Missing        []string `json:"missing,omitempty"`
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// StoredImage describes an image in the content-addressed image store
+type StoredImage struct {
+	ID        string   `json:"id"`   // SHA-256 of the file content
+	Path      string   `json:"path"` // Reference relative to the data root
+	Format    string   `json:"format"`
+	Width     int      `json:"width"`
+	Height    int      `json:"height"`
+	Size      int64    `json:"size"`
+	CreatedAt int64    `json:"createdAt"`
+	Refs      []string `json:"refs"` // Owners such as "history:<id>" or "template:<id>"
+
+	Encoding *ImageEncoding  `json:"encoding,omitempty"` // How the stored file was produced
+	Hash     *PerceptualHash `json:"hash,omitempty"`     // For similarity search
+
+	HashFailed bool `json:"hashFailed,omitempty"` // The file could not be decoded for hashing
+}
+
+// ImageEncoding records how a stored image was produced from its source
+type ImageEncoding struct {
+	SourceFormat string `json:"sourceFormat"`
+	PassThrough  bool   `json:"passThrough"`        // Source bytes were kept unchanged
+	Resized      bool   `json:"resized,omitempty"`  // Downscaled to the max dimension
+	Quality      int    `json:"quality,omitempty"`  // JPEG quality when re-encoded as JPEG
+	Metadata     bool   `json:"metadata,omitempty"` // Generation metadata is embedded
+}
+
+// StorageSettings controls the format generated images are stored in
+type StorageSettings struct {
+	Format       string `json:"format"`       // "png" (default), "original" or "jpeg"
+	JPEGQuality  int    `json:"jpegQuality"`  // 1-100, 90 by default
+	MaxDimension int    `json:"maxDimension"` // Longest side in pixels, 0 for no limit
+}
+
+// GarbageReport summarizes an image garbage collection run
+type GarbageReport struct {
+	DryRun         bool          `json:"dryRun"`
+	Scanned        int           `json:"scanned"`
+	Referenced     int           `json:"referenced"`
+	Deleted        int           `json:"deleted"`
+	ReclaimedBytes int64         `json:"reclaimedBytes"` // Bytes freed, or that would be freed in dry-run mode
+	Orphans        []OrphanImage `json:"orphans"`
+	Errors         []string      `json:"errors,omitempty"`
+}
+
+// OrphanImage is an image file that nothing references
+type OrphanImage struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Legacy bool   `json:"legacy,omitempty"` // In a folder outside the data root; listed, never deleted
+}
+
+// TagCount is an entry of the template tag index
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TemplateQuery filters templates by tags, author and language coverage
+type TemplateQuery struct {
+	Tags      []string `json:"tags"`
+	TagMode   string   `json:"tagMode"`   // "and" (default) requires every tag, "or" requires any
+	Author    string   `json:"author"`    // Exact author match, empty for any
+	Languages []string `json:"languages"` // Languages that must have non-empty name and content, e.g. "cn", "en"
+}
+
+// SearchOptions restricts a full-text search
+type SearchOptions struct {
+	Kinds []string `json:"kinds"` // "template", "bank", "history"; empty searches all
+	Limit int      `json:"limit"` // Maximum results, 50 if zero
+}
+
+// SearchResponse contains ranked search results
+type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"` // Matches before the limit was applied
+}
+
+// SearchResult is a single ranked search hit
+type SearchResult struct {
+	Kind       string            `json:"kind"`
+	ID         string            `json:"id"`
+	Title      string            `json:"title"`
+	Score      float64           `json:"score"`
+	Highlights []SearchHighlight `json:"highlights"`
+}
+
+// SearchHighlight is a snippet of a matching field with matches wrapped in <mark> tags
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+// HistoryQuery filters and pages through history records
+type HistoryQuery struct {
+	Provider   string   `json:"provider"`
+	Model      string   `json:"model"`
+	Size       string   `json:"size"`
+	TemplateID string   `json:"templateId"`
+	Prompt     string   `json:"prompt"`    // Case-insensitive substring of the prompt
+	From       int64    `json:"from"`      // Unix seconds, inclusive; 0 for no lower bound
+	To         int64    `json:"to"`        // Unix seconds, inclusive; 0 for no upper bound
+	HasImages  *bool    `json:"hasImages"` // nil matches records with and without images
+	Starred    *bool    `json:"starred"`   // nil matches starred and unstarred records
+	MinRating  int      `json:"minRating"` // Minimum rating, 0 for any
+	Labels     []string `json:"labels"`    // Labels the record must all have
+	Sort       string   `json:"sort"`      // "newest" (default) or "oldest"
+	Cursor     string   `json:"cursor"`    // NextCursor of the previous page, empty for the first page
+	Limit      int      `json:"limit"`     // Page size, 50 if zero
+}
+
+// HistoryPage is one page of history query results
+type HistoryPage struct {
+	Records    []HistoryRecord `json:"records"`
+	Total      int             `json:"total"`      // Records matching the filters
+	TotalAll   int             `json:"totalAll"`   // Records in history
+	NextCursor string          `json:"nextCursor"` // Empty when there are no more pages
+}
+
+// RegenerateOverrides changes parameters when replaying a history record.
+// Empty fields keep the recorded value.
+type RegenerateOverrides struct {
+	Prompt         string         `json:"prompt"`
+	Provider       string         `json:"provider"`
+	Model          string         `json:"model"`
+	Size           string         `json:"size"`
+	NegativePrompt *string        `json:"negativePrompt"`
+	Seed           *int64         `json:"seed"`
+	RandomSeed     bool           `json:"randomSeed"` // Ignore the recorded seed and pick a new one
+	Parameters     map[string]any `json:"parameters"`
+}
+
+// RegenerateResult is the outcome of replaying a history record
+type RegenerateResult struct {
+	Response *GenerateResponse `json:"response"`
+	Record   *HistoryRecord    `json:"record,omitempty"` // New history record when generation succeeded
+}
+
+// HistoryExportOptions configures a history export
+type HistoryExportOptions struct {
+	RecordIDs   []string `json:"recordIds"`   // Records to export, all when empty
+	Format      string   `json:"format"`      // "folder" (default) or "zip"
+	Destination string   `json:"destination"` // Folder or zip path; a dialog is shown when empty
+}
+
+// HistoryExportResult summarizes a history export
+type HistoryExportResult struct {
+	Path    string        `json:"path"`
+	Records int           `json:"records"`
+	Images  int           `json:"images"`
+	Missing []ExportIssue `json:"missing"`
+}
+
+// ImageExportOptions configures a bulk export of image files
+type ImageExportOptions struct {
+	RecordIDs   []string `json:"recordIds"`   // History records whose images are exported
+	ImageIDs    []string `json:"imageIds"`    // Individual history images
+	TemplateIDs []string `json:"templateIds"` // Templates whose cover images are exported
+	Destination string   `json:"destination"` // Folder; a dialog is shown when empty
+	Pattern     string   `json:"pattern"`     // File name pattern with {tokens}
+	Sidecar     string   `json:"sidecar"`     // "txt", "json" or empty for none
+	Overwrite   bool     `json:"overwrite"`   // Replace files that existed before the export instead of numbering new ones
+}
+
+// ImageExportResult summarizes a bulk image export
+type ImageExportResult struct {
+	Path     string        `json:"path"`
+	Exported int           `json:"exported"`
+	Files    []string      `json:"files"` // Exported image file names
+	Missing  []ExportIssue `json:"missing"`
+}
+
+// ImageExportProgress is emitted as an event after every image of a bulk export
+type ImageExportProgress struct {
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	File  string `json:"file,omitempty"`  // Name the image was written to
+	Error string `json:"error,omitempty"` // Why the image was skipped
+}
+
+// ExportIssue describes an image that could not be exported
+type ExportIssue struct {
+	RecordID string `json:"recordId"`
+	Path     string `json:"path"`
+	Reason   string `json:"reason"`
+}
+
+// ImageMetadata is the generation metadata embedded in (or read from) PNG text chunks
+type ImageMetadata struct {
+	Prompt         string            `json:"prompt"`
+	NegativePrompt string            `json:"negativePrompt,omitempty"`
+	Provider       string            `json:"provider,omitempty"`
+	Model          string            `json:"model,omitempty"`
+	Size           string            `json:"size,omitempty"`
+	Seed           *int64            `json:"seed,omitempty"`
+	TemplateID     string            `json:"templateId,omitempty"`
+	Parameters     string            `json:"parameters,omitempty"` // Raw A1111 "parameters" text, when read from a file
+	Chunks         map[string]string `json:"chunks,omitempty"`     // All text chunks by keyword, when read from a file
+}
+
+// UsageSettings configures cost estimation and spending limits
+type UsageSettings struct {
+	Currency string                           `json:"currency"`
+	Prices   map[string]map[string]ModelPrice `json:"prices"` // Keyed by provider, then model
+	Budgets  []UsageBudget                    `json:"budgets"`
+	User     string                           `json:"user"` // Recorded on ledger entries; defaults to the OS user
+}
+
+// ModelPrice is the price of one model in the configured currency
+type ModelPrice struct {
+	PerImage               float64 `json:"perImage"`
+	PerMillionInputTokens  float64 `json:"perMillionInputTokens,omitempty"`
+	PerMillionOutputTokens float64 `json:"perMillionOutputTokens,omitempty"`
+}
+
+// UsageBudget limits spending per day or month, optionally for one provider
+type UsageBudget struct {
+	Period   string  `json:"period"`             // "day" or "month"
+	Provider string  `json:"provider,omitempty"` // Empty applies to all providers
+	Limit    float64 `json:"limit"`
+}
+
+// UsageEntry is one generation recorded in the usage ledger
+type UsageEntry struct {
+	Timestamp    int64   `json:"timestamp"`
+	User         string  `json:"user"`
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	Images       int     `json:"images"`
+	InputTokens  int     `json:"inputTokens,omitempty"`
+	OutputTokens int     `json:"outputTokens,omitempty"`
+	Cost         float64 `json:"cost"`
+	Currency     string  `json:"currency"`
+	RequestID    string  `json:"requestId,omitempty"`
+}
+
+// UsageQuery selects and groups usage ledger entries
+type UsageQuery struct {
+	From     int64  `json:"from,omitempty"` // Unix seconds, inclusive
+	To       int64  `json:"to,omitempty"`   // Unix seconds, exclusive
+	Provider string `json:"provider,omitempty"`
+	User     string `json:"user,omitempty"`
+	GroupBy  string `json:"groupBy,omitempty"` // "day" (default), "provider", "model" or "user"
+}
+
+// UsageSummary aggregates usage ledger entries
+type UsageSummary struct {
+	Currency string         `json:"currency"`
+	Requests int            `json:"requests"`
+	Images   int            `json:"images"`
+	Cost     float64        `json:"cost"`
+	Groups   []UsageGroup   `json:"groups"`
+	Budgets  []BudgetStatus `json:"budgets"`
+}
+
+// UsageGroup is the aggregated usage for one group key
+type UsageGroup struct {
+	Key          string  `json:"key"`
+	Requests     int     `json:"requests"`
+	Images       int     `json:"images"`
+	InputTokens  int     `json:"inputTokens"`
+	OutputTokens int     `json:"outputTokens"`
+	Cost         float64 `json:"cost"`
+}
+
+// BudgetStatus reports spending against a budget in its current period
+type BudgetStatus struct {
+	Budget    UsageBudget `json:"budget"`
+	Spent     float64     `json:"spent"`
+	Remaining float64     `json:"remaining"`
+	Exceeded  bool        `json:"exceeded"`
+}
+
+// GenerationAttempt is one request sent to a provider, successful or not
+type GenerationAttempt struct {
+	Timestamp  int64  `json:"timestamp"`
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	Success    bool   `json:"success"`
+	ErrorCode  string `json:"errorCode,omitempty"`
+	LatencyMs  int64  `json:"latencyMs"`
+	Images     int    `json:"images"`
+	TemplateID string `json:"templateId,omitempty"`
+	Rejected   bool   `json:"rejected,omitempty"` // Refused before the request was sent
+}
+
+// AnalyticsQuery restricts history analytics to a time range and provider
+type AnalyticsQuery struct {
+	From     int64  `json:"from,omitempty"` // Unix seconds, inclusive
+	To       int64  `json:"to,omitempty"`   // Unix seconds, exclusive
+	Provider string `json:"provider,omitempty"`
+	Top      int    `json:"top,omitempty"` // Entries in the top lists, 10 by default
+}
+
+// HistoryAnalytics is aggregate statistics over history and generation attempts
+type HistoryAnalytics struct {
+	Generations    int               `json:"generations"` // History records in range
+	Images         int               `json:"images"`
+	PerDay         []AnalyticsBucket `json:"perDay"`
+	PerWeek        []AnalyticsBucket `json:"perWeek"`
+	PerProvider    []AnalyticsBucket `json:"perProvider"`
+	Models         []ModelStats      `json:"models"`
+	TopTemplates   []AnalyticsBucket `json:"topTemplates"`
+	TopBankOptions []BankOptionCount `json:"topBankOptions"`
+}
+
+// AnalyticsBucket counts history records for one key
+type AnalyticsBucket struct {
+	Key    string `json:"key"`
+	Count  int    `json:"count"`
+	Images int    `json:"images"`
+}
+
+// ModelStats is the success rate and latency of one model
+type ModelStats struct {
+	Provider     string         `json:"provider"`
+	Model        string         `json:"model"`
+	Attempts     int            `json:"attempts"`
+	Successes    int            `json:"successes"`
+	Failures     int            `json:"failures"`
+	Rejected     int            `json:"rejected"`     // Failures refused before dispatch
+	SuccessRate  float64        `json:"successRate"`  // 0-1
+	AvgLatencyMs int64          `json:"avgLatencyMs"` // Over requests sent to the provider
+	ErrorCodes   map[string]int `json:"errorCodes,omitempty"`
+}
+
+// BankOptionCount counts how often a bank option was selected for a placeholder
+type BankOptionCount struct {
+	Placeholder string `json:"placeholder"`
+	Value       string `json:"value"`
+	Count       int    `json:"count"`
+}
+
+// RetentionPolicy limits how much history is kept; zero values disable a rule.
+// Starred records are never deleted by the policy.
+type RetentionPolicy struct {
+	KeepLast       int     `json:"keepLast"`       // Keep only the N most recent unstarred records
+	MaxAgeDays     int     `json:"maxAgeDays"`     // Delete records older than this many days
+	MaxTotalSizeGB float64 `json:"maxTotalSizeGB"` // Delete the oldest records until images fit
+	RunAtStartup   bool    `json:"runAtStartup"`
+}
+
+// HistoryDeleteReport summarizes a bulk history deletion or its dry-run preview
+type HistoryDeleteReport struct {
+	DryRun         bool     `json:"dryRun"`
+	RecordIDs      []string `json:"recordIds"` // Records deleted, or that would be deleted
+	Deleted        int      `json:"deleted"`
+	ReclaimedBytes int64    `json:"reclaimedBytes"` // Image bytes no longer referenced by any record
+	Errors         []string `json:"errors,omitempty"`
+}
+
+// IngestRequest describes images to store as one history record
+type IngestRequest struct {
+	RecordID  string                 `json:"recordId,omitempty"` // Replaces the record with this ID; generated when empty
+	Images    []GeneratedImage       `json:"images"`             // URLs may be http(s) URLs, data URIs, local paths or image store references
+	Params    GenerationParams       `json:"params"`
+	Timestamp int64                  `json:"timestamp,omitempty"` // Unix seconds; now when zero
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// IngestResult is the record written by an ingest and the outcome for every image
+type IngestResult struct {
+	Record *HistoryRecord  `json:"record"`
+	Images []IngestedImage `json:"images"` // In request order
+	Failed int             `json:"failed"`
+}
+
+// IngestedImage is the outcome of ingesting one image
+type IngestedImage struct {
+	Source  string `json:"source"`
+	Ref     string `json:"ref,omitempty"` // Image store reference on success
+	Format  string `json:"format,omitempty"`
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+	Size    int64  `json:"size,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Warning string `json:"warning,omitempty"` // Stored, but not everything was kept
+}
+
+// PostProcessStep is one operation of a post-processing pipeline
+type PostProcessStep struct {
+	Type string `json:"type"` // resize, crop, pad, watermark or convert
+
+	// resize: exact width and height, one of them keeping the aspect ratio, or a scale factor
+	Width  int     `json:"width,omitempty"`
+	Height int     `json:"height,omitempty"`
+	Scale  float64 `json:"scale,omitempty"`
+
+	// crop and pad: target aspect ratio such as "16:9"
+	Aspect string `json:"aspect,omitempty"`
+	Color  string `json:"color,omitempty"` // Pad or text color: #RRGGBB, #RRGGBBAA or "transparent"
+
+	// watermark: ASCII text or a logo image source
+	Text     string  `json:"text,omitempty"`
+	Image    string  `json:"image,omitempty"`
+	Position string  `json:"position,omitempty"` // top-left, top, top-right, left, center, right, bottom-left, bottom, bottom-right
+	Opacity  float64 `json:"opacity,omitempty"`  // 0-1
+	Size     float64 `json:"size,omitempty"`     // Text height or logo width as a fraction of the image width
+
+	// convert
+	Format  string `json:"format,omitempty"` // png or jpeg
+	Quality int    `json:"quality,omitempty"`
+}
+
+// PostProcessPipeline is a named sequence of post-processing steps
+type PostProcessPipeline struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Steps       []PostProcessStep `json:"steps"`
+}
+
+// GridOptions controls the layout of a contact sheet
+type GridOptions struct {
+	Columns      int      `json:"columns,omitempty"`      // Default: a near-square grid
+	TileSize     int      `json:"tileSize,omitempty"`     // Longest tile side in pixels
+	Padding      int      `json:"padding,omitempty"`      // Gap around and between tiles in pixels
+	Background   string   `json:"background,omitempty"`   // #RRGGBB
+	Caption      string   `json:"caption,omitempty"`      // none, model, selections, prompt or seed (ASCII only, see ComposeGrid)
+	Captions     []string `json:"captions,omitempty"`     // Caption per image, overriding Caption
+	CaptionColor string   `json:"captionColor,omitempty"` // #RRGGBB
+	Title        string   `json:"title,omitempty"`        // Prompt of the saved record
+}
+
+// PerceptualHash holds 64-bit perceptual hashes of an image as hex strings
+type PerceptualHash struct {
+	AHash string `json:"aHash"` // Average hash
+	DHash string `json:"dHash"` // Difference hash
+	PHash string `json:"pHash"` // DCT hash
+}
+
+// SimilarImage is a history image or template cover similar to a query image
+type SimilarImage struct {
+	Ref        string          `json:"ref"`
+	ImageID    string          `json:"imageId,omitempty"`    // History image ID
+	RecordID   string          `json:"recordId,omitempty"`   // History record holding the image
+	TemplateID string          `json:"templateId,omitempty"` // Template using the image as a cover
+	Timestamp  int64           `json:"timestamp,omitempty"`
+	Distance   int             `json:"distance"` // Hamming distance of the pHashes, 0-64
+	Hash       *PerceptualHash `json:"hash"`
+}
+
+// DuplicateGroup is a set of images linked by pairs within the threshold, oldest first.
+// Images at the ends of a chain may differ by more than the threshold; each Distance
+// is measured to the first image.
+type DuplicateGroup struct {
+	Images []SimilarImage `json:"images"`
+}
+
+// DuplicateReport lists groups of near-duplicate images
+type DuplicateReport struct {
+	Threshold  int              `json:"threshold"`
+	Scanned    int              `json:"scanned"`
+	Duplicates int              `json:"duplicates"` // Images beyond the first of every group
+	Groups     []DuplicateGroup `json:"groups"`
 }
@@ -2,6 +2,7 @@
 import { useState, useEffect } from "react";
 import * as App from "@backend/App";
 import { Loader2, ImageOff } from "lucide-react";
+import { localImageUrl } from "@/lib/utils";
 
 // Global cache to store loaded image data
 const globalImageCache = new Map<string, string>();
@@ -10,22 +11,27 @@ const globalPendingPromises = new Map<string, Promise<string>>();
 interface LocalImageProps extends React.ImgHTMLAttributes<HTMLImageElement> {
     src: string;
     fallback?: React.ReactNode;
+    thumbnailSize?: number; // Load a cached thumbnail instead of the full image
 }
 
-export function LocalImage({ src, className, alt, fallback, ...props }: LocalImageProps) {
+export function LocalImage({ src, className, alt, fallback, thumbnailSize, ...props }: LocalImageProps) {
+    // Thumbnails and full images are cached separately
+    const cacheKey = thumbnailSize ? `${src}@${thumbnailSize}` : src;
+    // Remote URLs, data URIs and data root images are loaded by the webview directly
+    const directUrl = src && (src.startsWith("http") || src.startsWith("data:")) ? src : localImageUrl(src, thumbnailSize);
 
     // Initialize state from cache if available to prevent flash
     const [imageSrc, setImageSrc] = useState<string>(() => {
         if (!src) return "";
-        if (src.startsWith("http") || src.startsWith("data:")) return src;
-        return globalImageCache.get(src) || "";
+        if (directUrl) return directUrl;
+        return globalImageCache.get(cacheKey) || "";
     });
 
     // Only show loading if we don't have the image in cache
     const [loading, setLoading] = useState(() => {
         if (!src) return false;
-        if (src.startsWith("http") || src.startsWith("data:")) return false;
-        return !globalImageCache.has(src);
+        if (directUrl) return false;
+        return !globalImageCache.has(cacheKey);
     });
 
     const [error, setError] = useState(false);
@@ -38,16 +44,16 @@ export function LocalImage({ src, className, alt, fallback, ...props }: LocalIma
         }
 
         // Direct URLs
-        if (src.startsWith("http") || src.startsWith("data:")) {
-            setImageSrc(src);
+        if (directUrl) {
+            setImageSrc(directUrl);
             setLoading(false);
             setError(false);
             return;
         }
 
         // Check cache first
-        if (globalImageCache.has(src)) {
-            setImageSrc(globalImageCache.get(src)!);
+        if (globalImageCache.has(cacheKey)) {
+            setImageSrc(globalImageCache.get(cacheKey)!);
             setLoading(false);
             setError(false);
             return;
@@ -60,19 +66,20 @@ export function LocalImage({ src, className, alt, fallback, ...props }: LocalIma
 
         const loadLocalImage = async () => {
             try {
-                let promise = globalPendingPromises.get(src);
+                let promise = globalPendingPromises.get(cacheKey);
                 if (!promise) {
-                    // Start new request
-                    // @ts-ignore
-                    promise = App.ReadImageFile(src) as Promise<string>;
-                    globalPendingPromises.set(src, promise);
+                    // Start new request (thumbnail first, falling back to the full image)
+                    promise = thumbnailSize
+                        ? App.GetThumbnail(src, thumbnailSize).catch(() => App.ReadImageFile(src))
+                        : App.ReadImageFile(src);
+                    globalPendingPromises.set(cacheKey, promise);
                 }
 
                 const base64Data = await promise;
 
                 // Update cache
                 if (base64Data) {
-                    globalImageCache.set(src, base64Data);
+                    globalImageCache.set(cacheKey, base64Data);
                 }
 
                 // Update state if still mounted
@@ -97,10 +104,10 @@ export function LocalImage({ src, className, alt, fallback, ...props }: LocalIma
 
                 // To be safe, we verify if the promise in map is still us? Not really needed if we just delete by key.
                 // Note: If fetch failed, we also want to remove so retry checks can happen (though undefined cache will trigger retry).
-                if (globalPendingPromises.get(src) !== undefined) {
+                if (globalPendingPromises.get(cacheKey) !== undefined) {
                     // We check if the promise in map is settled.
                     // But we are in finally block of awaiting that promise, so it IS settled (or rejected).
-                    globalPendingPromises.delete(src);
+                    globalPendingPromises.delete(cacheKey);
                 }
 
                 if (isMounted) setLoading(false);
@@ -112,7 +119,7 @@ export function LocalImage({ src, className, alt, fallback, ...props }: LocalIma
         return () => {
             isMounted = false;
         };
-    }, [src]);
+    }, [src, cacheKey, directUrl, thumbnailSize]);
 
     if (error) {
         return fallback || (
@@ -130,5 +137,5 @@ export function LocalImage({ src, className, alt, fallback, ...props }: LocalIma
         );
     }
 
-    return <img src={imageSrc} className={className} alt={alt} {...props} />;
+    return <img src={imageSrc} className={className} alt={alt} onError={() => setError(true)} {...props} />;
 }
@@ -15,8 +15,13 @@ import { useLanguage } from "../contexts/LanguageContext";
 import { translations } from "../utils/i18n";
 import BlurFade from "@/components/ui/blur-fade";
 import { Magnetic } from "@/components/ui/magnetic";
+import { localImageUrl } from "@/lib/utils";
 
-function HistoryImage({ src, alt, className, ...props }: React.ImgHTMLAttributes<HTMLImageElement>) {
+interface HistoryImageProps extends React.ImgHTMLAttributes<HTMLImageElement> {
+    thumbnailSize?: number; // Load a cached thumbnail instead of the full image
+}
+
+function HistoryImage({ src, alt, className, thumbnailSize, ...props }: HistoryImageProps) {
     const [imageSrc, setImageSrc] = useState<string>("");
     const [error, setError] = useState(false);
 
@@ -29,15 +34,24 @@ function HistoryImage({ src, alt, className, ...props }: React.ImgHTMLAttributes
             return;
         }
 
-        // It's a local file, load via backend
-        // @ts-ignore
-        App.ReadImageFile(src)
+        // Images under the data root are served by the asset handler
+        const assetUrl = localImageUrl(src, thumbnailSize);
+        if (assetUrl) {
+            setImageSrc(assetUrl);
+            return;
+        }
+
+        // It's a local file, load via backend (thumbnail first, falling back to the full image)
+        const load = thumbnailSize
+            ? App.GetThumbnail(src, thumbnailSize).catch(() => App.ReadImageFile(src))
+            : App.ReadImageFile(src);
+        load
             .then((data: string) => setImageSrc(data))
             .catch((err: any) => {
                 console.error("Failed to load image:", src, err);
                 setError(true);
             });
-    }, [src]);
+    }, [src, thumbnailSize]);
 
     if (error) {
         return <div className={`bg-muted/30 flex items-center justify-center text-muted-foreground text-xs p-4 ${className}`}>Image not found</div>;
@@ -47,7 +61,7 @@ function HistoryImage({ src, alt, className, ...props }: React.ImgHTMLAttributes
         return <div className={`animate-pulse bg-muted/30 ${className}`} />;
     }
 
-    return <img src={imageSrc} alt={alt} className={className} {...props} />;
+    return <img src={imageSrc} alt={alt} className={className} onError={() => setError(true)} {...props} />;
 }
 
 
@@ -203,6 +217,7 @@ export function History() {
                                     <HistoryImage
                                         src={record.images[0]?.url}
                                         alt="Generated"
+                                        thumbnailSize={512}
                                         className="w-full h-auto block"
                                         loading="lazy"
                                     />
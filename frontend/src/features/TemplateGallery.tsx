@@ -36,6 +36,7 @@ export function TemplateGallery({ templates, banks, onSelect }: TemplateGalleryP
                                     <div className="relative aspect-auto overflow-hidden rounded-md border bg-muted">
                                         <LocalImage
                                             src={tpl.imageUrl}
+                                            thumbnailSize={512}
                                             alt={tpl.name[language] || tpl.name.cn || tpl.name.en}
                                             className="h-full w-full object-cover transition-transform duration-300 group-hover:scale-105"
                                         />
@@ -59,12 +59,17 @@ export function TemplateWorkstation({ template: initialTemplate, onBack, onUpdat
     // showSettings removed
     const [generating, setGenerating] = useState(false);
     const [generatedImages, setGeneratedImages] = useState<string[]>([]);
+    // Request behind each generated image, so saving replays exactly what was generated
+    const [imageRequests, setImageRequests] = useState<Record<string, GenerationParams>>({});
     const [viewingImage, setViewingImage] = useState<string | null>(null);
     const [showInsertModal, setShowInsertModal] = useState(false);
     const [showDeleteDialog, setShowDeleteDialog] = useState(false);
     const [insertSearch, setInsertSearch] = useState("");
     const [insertCategory, setInsertCategory] = useState<string>("all");
     const [refImages, setRefImages] = useState<string[]>([]);
+    // Saved post-processing pipelines; the chosen one runs on generated images
+    const [pipelines, setPipelines] = useState<string[]>([]);
+    const [pipeline, setPipeline] = useState<string>("");
     const [displayLang, setDisplayLang] = useState<'cn' | 'en'>(language === 'cn' ? 'cn' : 'en');
 
     // Cover setting state
@@ -94,6 +99,9 @@ export function TemplateWorkstation({ template: initialTemplate, onBack, onUpdat
             const cats = await App.LoadCategories() as CategoryMap;
             setCategories(cats || {});
 
+            const savedPipelines = await App.GetPostProcessPipelines();
+            setPipelines((savedPipelines || []).map(p => p.name));
+
             if (!initialConfig) {
                 // @ts-ignore
                 const cfg = await App.GetConfig() as ConfigResponse;
@@ -254,13 +262,22 @@ export function TemplateWorkstation({ template: initialTemplate, onBack, onUpdat
                 provider: genSettings.provider,
                 model: genSettings.model,
                 size: genSettings.size,
-                images: refImages
+                images: [...refImages],
+                templateId: template.id,
+                templateRevision: template.revision,
+                language: displayLang,
+                selections: { ...variableValues },
+                ...(pipeline ? { pipeline } : {})
             };
             // @ts-ignore
             const res = await App.GenerateImage(req);
             if (res.success && res.images && res.images.length > 0) {
                 const newImageUrl = res.images[0].url;
                 setGeneratedImages(prev => [newImageUrl, ...prev]);
+                setImageRequests(prev => ({
+                    ...prev,
+                    [newImageUrl]: res.seed !== undefined ? { ...req, seed: res.seed } : req
+                }));
                 setViewingImage(newImageUrl);
             } else {
                 console.error("Generation failed:", res.error);
@@ -276,12 +293,12 @@ export function TemplateWorkstation({ template: initialTemplate, onBack, onUpdat
     };
 
     const handleSaveHistory = async (imageUrl: string) => {
-        if (!imageUrl) return;
+        const req = imageRequests[imageUrl];
+        if (!imageUrl || !req) return;
         setIsSavingHistory(true);
         try {
-            const prompt = getResolvedPrompt();
             // @ts-ignore
-            await App.DownloadImageAndSaveHistory(imageUrl, prompt, genSettings.provider, genSettings.model, genSettings.size, {});
+            await App.SaveGenerationToHistory(req, [{ id: "", url: imageUrl }]);
             setHistorySavedSuccess(true);
             toast.success(t.savedToHistory);
         } catch (e) {
@@ -564,6 +581,18 @@ export function TemplateWorkstation({ template: initialTemplate, onBack, onUpdat
                                 ))}
                             </SelectContent>
                         </Select>
+
+                        {pipelines.length > 0 && (
+                            <Select value={pipeline || "none"} onValueChange={val => setPipeline(val === "none" ? "" : val)}>
+                                <SelectTrigger className="h-8 w-[140px] text-xs">
+                                    <SelectValue placeholder={t.postProcessing} />
+                                </SelectTrigger>
+                                <SelectContent align="end">
+                                    <SelectItem value="none" className="text-xs">{t.noPostProcessing}</SelectItem>
+                                    {pipelines.map(name => <SelectItem key={name} value={name} className="text-xs">{name}</SelectItem>)}
+                                </SelectContent>
+                            </Select>
+                        )}
                     </div>
                 </div>
             </header>
@@ -31,3 +31,17 @@ export function getCategoryColor(colorName?: string): string {
     if (!colorName) return colorMap.default;
     return colorMap[colorName] || colorMap.default;
 }
+
+/**
+ * Returns the asset server URL of an image stored under the data root, or null for
+ * remote URLs, data URIs and absolute paths, which must be loaded another way.
+ * Pass thumbnailSize to get a cached thumbnail instead of the full image.
+ */
+export function localImageUrl(ref: string, thumbnailSize?: number): string | null {
+    if (!ref || /^(https?:|data:)/.test(ref)) return null;
+    // Absolute paths (POSIX, Windows drive or UNC) live outside the data root
+    if (ref.startsWith("/") || ref.startsWith("\\") || /^[a-zA-Z]:[\\/]/.test(ref)) return null;
+
+    const path = ref.split("/").map(encodeURIComponent).join("/");
+    return `/localimg/${path}${thumbnailSize ? `?thumb=${thumbnailSize}` : ""}`;
+}
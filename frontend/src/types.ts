@@ -7,6 +7,7 @@ export interface Template {
     author: string;
     tags?: string[];
     language?: string[];
+    revision?: number;
 }
 
 export interface BankItem {
@@ -51,6 +52,9 @@ export interface ProviderConfig {
 export interface ModelCapabilities {
     supportsReferenceImage: boolean;
     maxReferenceImages: number;
+    maxImageBytes?: number;
+    maxImagePixels?: number;
+    acceptedMimeTypes?: string[];
 }
 
 export interface ConfigResponse {
@@ -70,6 +74,36 @@ export interface GenerationParams {
     size: string;
     images?: string[];
     parameters?: { [key: string]: any };
+    negativePrompt?: string;
+    seed?: number;
+    templateId?: string;
+    templateRevision?: number;
+    language?: string;
+    selections?: { [key: string]: string };
+    pipeline?: string;
+    postProcess?: PostProcessPipeline;
+}
+
+export interface PostProcessStep {
+    type: "resize" | "crop" | "pad" | "watermark" | "convert";
+    width?: number;
+    height?: number;
+    scale?: number;
+    aspect?: string;
+    color?: string;
+    text?: string;
+    image?: string;
+    position?: string;
+    opacity?: number;
+    size?: number;
+    format?: string;
+    quality?: number;
+}
+
+export interface PostProcessPipeline {
+    name: string;
+    description?: string;
+    steps: PostProcessStep[];
 }
 
 export interface HistoryRecord {
@@ -78,4 +112,5 @@ export interface HistoryRecord {
     images: GeneratedImage[];
     timestamp: number;
     metadata?: { [key: string]: any };
+    sourceRecords?: string[];
 }
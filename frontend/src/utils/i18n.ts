@@ -29,6 +29,8 @@ export const translations = {
         provider: "服务商",
         model: "模型",
         size: "尺寸",
+        postProcessing: "后期处理",
+        noPostProcessing: "不处理",
         bankNotFound: "未找到词库",
         selectSizeToVisualize: "选择尺寸以查看比例",
         noTemplatesFound: "未找到模板",
@@ -133,6 +135,8 @@ export const translations = {
         provider: "Provider",
         model: "Model",
         size: "Size",
+        postProcessing: "Post-processing",
+        noPostProcessing: "None",
         bankNotFound: "Bank not found",
         selectSizeToVisualize: "Select a size to visualize",
         noTemplatesFound: "NO TEMPLATES FOUND",
@@ -4,12 +4,24 @@ import {backend} from '../models';
 
 export function AddHistoryRecord(arg1:backend.HistoryRecord):Promise<void>;
 
+export function ApplyRetention(arg1:boolean):Promise<backend.HistoryDeleteReport>;
+
+export function CollectGarbage(arg1:boolean):Promise<backend.GarbageReport>;
+
+export function CompactHistory():Promise<void>;
+
+export function ComposeGrid(arg1:Array<string>,arg2:backend.GridOptions):Promise<backend.HistoryRecord>;
+
 export function DeleteAIHistoryRecord(arg1:string):Promise<void>;
 
 export function DeleteBank(arg1:string):Promise<void>;
 
 export function DeleteCategory(arg1:string):Promise<void>;
 
+export function DeleteHistoryByQuery(arg1:backend.HistoryQuery,arg2:boolean):Promise<backend.HistoryDeleteReport>;
+
+export function DeletePostProcessPipeline(arg1:string):Promise<void>;
+
 export function DeleteTemplate(arg1:string):Promise<void>;
 
 export function DownloadImageAndSaveHistory(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:Record<string, any>):Promise<string>;
@@ -20,14 +32,52 @@ export function EnsureCategory(arg1:string,arg2:backend.Category):Promise<void>;
 
 export function EnsureTemplate(arg1:backend.Template):Promise<void>;
 
+export function ExportHistory(arg1:backend.HistoryExportOptions):Promise<backend.HistoryExportResult>;
+
+export function ExportImages(arg1:backend.ImageExportOptions):Promise<backend.ImageExportResult>;
+
+export function FindSimilarImages(arg1:string,arg2:number):Promise<Array<backend.SimilarImage>>;
+
 export function GenerateImage(arg1:backend.GenerateRequest):Promise<backend.GenerateResponse>;
 
 export function GetConfig():Promise<backend.ConfigResponse>;
 
+export function GetDataRoot():Promise<backend.DataRootInfo>;
+
+export function GetDuplicateReport(arg1:number):Promise<backend.DuplicateReport>;
+
+export function GetHistoryAnalytics(arg1:backend.AnalyticsQuery):Promise<backend.HistoryAnalytics>;
+
+export function GetHistoryLabels():Promise<Array<backend.TagCount>>;
+
+export function GetImageInfo(arg1:string):Promise<backend.StoredImage>;
+
+export function GetPostProcessPipelines():Promise<Array<backend.PostProcessPipeline>>;
+
 export function GetProviders():Promise<backend.ProvidersResponse>;
 
+export function GetRetentionPolicy():Promise<backend.RetentionPolicy>;
+
+export function GetStorageSettings():Promise<backend.StorageSettings>;
+
+export function GetTagIndex():Promise<Array<backend.TagCount>>;
+
+export function GetThumbnail(arg1:string,arg2:number):Promise<string>;
+
+export function GetUsageEntries(arg1:backend.UsageQuery):Promise<Array<backend.UsageEntry>>;
+
+export function GetUsageSettings():Promise<backend.UsageSettings>;
+
+export function GetUsageSummary(arg1:backend.UsageQuery):Promise<backend.UsageSummary>;
+
 export function GetUserDownloadDir():Promise<string>;
 
+export function ImportImageAsHistory(arg1:string):Promise<backend.HistoryRecord>;
+
+export function ImportImageMetadata(arg1:string):Promise<backend.ImageMetadata>;
+
+export function IngestImages(arg1:backend.IngestRequest):Promise<backend.IngestResult>;
+
 export function LoadAIHistory():Promise<Array<backend.HistoryRecord>>;
 
 export function LoadBanks():Promise<backend.BankMap>;
@@ -36,20 +86,66 @@ export function LoadCategories():Promise<backend.CategoryMap>;
 
 export function LoadTemplates():Promise<Array<backend.Template>>;
 
+export function MergeTags(arg1:Array<string>,arg2:string):Promise<number>;
+
+export function MigrateImages():Promise<backend.ImageMigrationReport>;
+
+export function PreviewPostProcess(arg1:string,arg2:backend.PostProcessPipeline):Promise<string>;
+
+export function QueryHistory(arg1:backend.HistoryQuery):Promise<backend.HistoryPage>;
+
+export function QueryTemplates(arg1:backend.TemplateQuery):Promise<Array<backend.Template>>;
+
+export function RateHistoryRecord(arg1:string,arg2:number):Promise<backend.HistoryRecord>;
+
 export function ReadImageFile(arg1:string):Promise<string>;
 
+export function RebuildImageIndex():Promise<backend.ImageMigrationReport>;
+
+export function RebuildSearchIndex():Promise<void>;
+
+export function RegenerateFromHistory(arg1:string,arg2:backend.RegenerateOverrides):Promise<backend.RegenerateResult>;
+
+export function RenameTag(arg1:string,arg2:string):Promise<number>;
+
+export function ResolveImagePath(arg1:string):Promise<string>;
+
+export function ResolveReferenceImages(arg1:Array<string>):Promise<Array<backend.ReferenceImageInfo>>;
+
 export function SaveAIHistory(arg1:Array<backend.HistoryRecord>):Promise<void>;
 
 export function SaveBanks(arg1:backend.BankMap):Promise<void>;
 
 export function SaveCategories(arg1:backend.CategoryMap):Promise<void>;
 
+export function SaveGenerationToHistory(arg1:backend.GenerateRequest,arg2:Array<backend.GeneratedImage>):Promise<backend.HistoryRecord>;
+
 export function SaveImageFile(arg1:Array<number>,arg2:string):Promise<void>;
 
+export function SavePostProcessPipeline(arg1:backend.PostProcessPipeline):Promise<void>;
+
 export function SaveTemplates(arg1:Array<backend.Template>):Promise<void>;
 
+export function Search(arg1:string,arg2:backend.SearchOptions):Promise<backend.SearchResponse>;
+
 export function SelectReferenceImages(arg1:boolean):Promise<Array<string>>;
 
 export function SetConfig(arg1:backend.ConfigRequest):Promise<void>;
 
+export function SetDataRoot(arg1:string):Promise<backend.ImageMigrationReport>;
+
+export function SetHistoryLabels(arg1:string,arg2:Array<string>):Promise<backend.HistoryRecord>;
+
+export function SetHistoryNote(arg1:string,arg2:string):Promise<backend.HistoryRecord>;
+
+export function SetRetentionPolicy(arg1:backend.RetentionPolicy):Promise<void>;
+
+export function SetStorageSettings(arg1:backend.StorageSettings):Promise<void>;
+
 export function SetTemplateCover(arg1:string,arg2:string):Promise<string>;
+
+export function SetUsageSettings(arg1:backend.UsageSettings):Promise<void>;
+
+export function StarHistoryRecord(arg1:string,arg2:boolean):Promise<backend.HistoryRecord>;
+
+export function WarmUpThumbnails():Promise<void>;
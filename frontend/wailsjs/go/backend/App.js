@@ -6,6 +6,22 @@ export function AddHistoryRecord(arg1) {
   return window['go']['backend']['App']['AddHistoryRecord'](arg1);
 }
 
+export function ApplyRetention(arg1) {
+  return window['go']['backend']['App']['ApplyRetention'](arg1);
+}
+
+export function CollectGarbage(arg1) {
+  return window['go']['backend']['App']['CollectGarbage'](arg1);
+}
+
+export function CompactHistory() {
+  return window['go']['backend']['App']['CompactHistory']();
+}
+
+export function ComposeGrid(arg1, arg2) {
+  return window['go']['backend']['App']['ComposeGrid'](arg1, arg2);
+}
+
 export function DeleteAIHistoryRecord(arg1) {
   return window['go']['backend']['App']['DeleteAIHistoryRecord'](arg1);
 }
@@ -18,6 +34,14 @@ export function DeleteCategory(arg1) {
   return window['go']['backend']['App']['DeleteCategory'](arg1);
 }
 
+export function DeleteHistoryByQuery(arg1, arg2) {
+  return window['go']['backend']['App']['DeleteHistoryByQuery'](arg1, arg2);
+}
+
+export function DeletePostProcessPipeline(arg1) {
+  return window['go']['backend']['App']['DeletePostProcessPipeline'](arg1);
+}
+
 export function DeleteTemplate(arg1) {
   return window['go']['backend']['App']['DeleteTemplate'](arg1);
 }
@@ -38,6 +62,18 @@ export function EnsureTemplate(arg1) {
   return window['go']['backend']['App']['EnsureTemplate'](arg1);
 }
 
+export function ExportHistory(arg1) {
+  return window['go']['backend']['App']['ExportHistory'](arg1);
+}
+
+export function ExportImages(arg1) {
+  return window['go']['backend']['App']['ExportImages'](arg1);
+}
+
+export function FindSimilarImages(arg1, arg2) {
+  return window['go']['backend']['App']['FindSimilarImages'](arg1, arg2);
+}
+
 export function GenerateImage(arg1) {
   return window['go']['backend']['App']['GenerateImage'](arg1);
 }
@@ -46,14 +82,78 @@ export function GetConfig() {
   return window['go']['backend']['App']['GetConfig']();
 }
 
+export function GetDataRoot() {
+  return window['go']['backend']['App']['GetDataRoot']();
+}
+
+export function GetDuplicateReport(arg1) {
+  return window['go']['backend']['App']['GetDuplicateReport'](arg1);
+}
+
+export function GetHistoryAnalytics(arg1) {
+  return window['go']['backend']['App']['GetHistoryAnalytics'](arg1);
+}
+
+export function GetHistoryLabels() {
+  return window['go']['backend']['App']['GetHistoryLabels']();
+}
+
+export function GetImageInfo(arg1) {
+  return window['go']['backend']['App']['GetImageInfo'](arg1);
+}
+
+export function GetPostProcessPipelines() {
+  return window['go']['backend']['App']['GetPostProcessPipelines']();
+}
+
 export function GetProviders() {
   return window['go']['backend']['App']['GetProviders']();
 }
 
+export function GetRetentionPolicy() {
+  return window['go']['backend']['App']['GetRetentionPolicy']();
+}
+
+export function GetStorageSettings() {
+  return window['go']['backend']['App']['GetStorageSettings']();
+}
+
+export function GetTagIndex() {
+  return window['go']['backend']['App']['GetTagIndex']();
+}
+
+export function GetThumbnail(arg1, arg2) {
+  return window['go']['backend']['App']['GetThumbnail'](arg1, arg2);
+}
+
+export function GetUsageEntries(arg1) {
+  return window['go']['backend']['App']['GetUsageEntries'](arg1);
+}
+
+export function GetUsageSettings() {
+  return window['go']['backend']['App']['GetUsageSettings']();
+}
+
+export function GetUsageSummary(arg1) {
+  return window['go']['backend']['App']['GetUsageSummary'](arg1);
+}
+
 export function GetUserDownloadDir() {
   return window['go']['backend']['App']['GetUserDownloadDir']();
 }
 
+export function ImportImageAsHistory(arg1) {
+  return window['go']['backend']['App']['ImportImageAsHistory'](arg1);
+}
+
+export function ImportImageMetadata(arg1) {
+  return window['go']['backend']['App']['ImportImageMetadata'](arg1);
+}
+
+export function IngestImages(arg1) {
+  return window['go']['backend']['App']['IngestImages'](arg1);
+}
+
 export function LoadAIHistory() {
   return window['go']['backend']['App']['LoadAIHistory']();
 }
@@ -70,10 +170,58 @@ export function LoadTemplates() {
   return window['go']['backend']['App']['LoadTemplates']();
 }
 
+export function MergeTags(arg1, arg2) {
+  return window['go']['backend']['App']['MergeTags'](arg1, arg2);
+}
+
+export function MigrateImages() {
+  return window['go']['backend']['App']['MigrateImages']();
+}
+
+export function PreviewPostProcess(arg1, arg2) {
+  return window['go']['backend']['App']['PreviewPostProcess'](arg1, arg2);
+}
+
+export function QueryHistory(arg1) {
+  return window['go']['backend']['App']['QueryHistory'](arg1);
+}
+
+export function QueryTemplates(arg1) {
+  return window['go']['backend']['App']['QueryTemplates'](arg1);
+}
+
+export function RateHistoryRecord(arg1, arg2) {
+  return window['go']['backend']['App']['RateHistoryRecord'](arg1, arg2);
+}
+
 export function ReadImageFile(arg1) {
   return window['go']['backend']['App']['ReadImageFile'](arg1);
 }
 
+export function RebuildImageIndex() {
+  return window['go']['backend']['App']['RebuildImageIndex']();
+}
+
+export function RebuildSearchIndex() {
+  return window['go']['backend']['App']['RebuildSearchIndex']();
+}
+
+export function RegenerateFromHistory(arg1, arg2) {
+  return window['go']['backend']['App']['RegenerateFromHistory'](arg1, arg2);
+}
+
+export function RenameTag(arg1, arg2) {
+  return window['go']['backend']['App']['RenameTag'](arg1, arg2);
+}
+
+export function ResolveImagePath(arg1) {
+  return window['go']['backend']['App']['ResolveImagePath'](arg1);
+}
+
+export function ResolveReferenceImages(arg1) {
+  return window['go']['backend']['App']['ResolveReferenceImages'](arg1);
+}
+
 export function SaveAIHistory(arg1) {
   return window['go']['backend']['App']['SaveAIHistory'](arg1);
 }
@@ -86,14 +234,26 @@ export function SaveCategories(arg1) {
   return window['go']['backend']['App']['SaveCategories'](arg1);
 }
 
+export function SaveGenerationToHistory(arg1, arg2) {
+  return window['go']['backend']['App']['SaveGenerationToHistory'](arg1, arg2);
+}
+
 export function SaveImageFile(arg1, arg2) {
   return window['go']['backend']['App']['SaveImageFile'](arg1, arg2);
 }
 
+export function SavePostProcessPipeline(arg1) {
+  return window['go']['backend']['App']['SavePostProcessPipeline'](arg1);
+}
+
 export function SaveTemplates(arg1) {
   return window['go']['backend']['App']['SaveTemplates'](arg1);
 }
 
+export function Search(arg1, arg2) {
+  return window['go']['backend']['App']['Search'](arg1, arg2);
+}
+
 export function SelectReferenceImages(arg1) {
   return window['go']['backend']['App']['SelectReferenceImages'](arg1);
 }
@@ -102,6 +262,38 @@ export function SetConfig(arg1) {
   return window['go']['backend']['App']['SetConfig'](arg1);
 }
 
+export function SetDataRoot(arg1) {
+  return window['go']['backend']['App']['SetDataRoot'](arg1);
+}
+
+export function SetHistoryLabels(arg1, arg2) {
+  return window['go']['backend']['App']['SetHistoryLabels'](arg1, arg2);
+}
+
+export function SetHistoryNote(arg1, arg2) {
+  return window['go']['backend']['App']['SetHistoryNote'](arg1, arg2);
+}
+
+export function SetRetentionPolicy(arg1) {
+  return window['go']['backend']['App']['SetRetentionPolicy'](arg1);
+}
+
+export function SetStorageSettings(arg1) {
+  return window['go']['backend']['App']['SetStorageSettings'](arg1);
+}
+
 export function SetTemplateCover(arg1, arg2) {
   return window['go']['backend']['App']['SetTemplateCover'](arg1, arg2);
 }
+
+export function SetUsageSettings(arg1) {
+  return window['go']['backend']['App']['SetUsageSettings'](arg1);
+}
+
+export function StarHistoryRecord(arg1, arg2) {
+  return window['go']['backend']['App']['StarHistoryRecord'](arg1, arg2);
+}
+
+export function WarmUpThumbnails() {
+  return window['go']['backend']['App']['WarmUpThumbnails']();
+}
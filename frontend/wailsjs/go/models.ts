@@ -18,6 +18,40 @@ export namespace backend {
 	        this.requestId = source["requestId"];
 	    }
 	}
+	export class AnalyticsBucket {
+	    key: string;
+	    count: number;
+	    images: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnalyticsBucket(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.count = source["count"];
+	        this.images = source["images"];
+	    }
+	}
+	export class AnalyticsQuery {
+	    from?: number;
+	    to?: number;
+	    provider?: string;
+	    top?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AnalyticsQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.provider = source["provider"];
+	        this.top = source["top"];
+	    }
+	}
 	export class BankItem {
 	    label: Record<string, string>;
 	    category: string;
@@ -34,6 +68,74 @@ export namespace backend {
 	        this.options = source["options"];
 	    }
 	}
+	export class BankOptionCount {
+	    placeholder: string;
+	    value: string;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BankOptionCount(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.placeholder = source["placeholder"];
+	        this.value = source["value"];
+	        this.count = source["count"];
+	    }
+	}
+	export class UsageBudget {
+	    period: string;
+	    provider?: string;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageBudget(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.period = source["period"];
+	        this.provider = source["provider"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class BudgetStatus {
+	    budget: UsageBudget;
+	    spent: number;
+	    remaining: number;
+	    exceeded: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new BudgetStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.budget = this.convertValues(source["budget"], UsageBudget);
+	        this.spent = source["spent"];
+	        this.remaining = source["remaining"];
+	        this.exceeded = source["exceeded"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class Category {
 	    id: string;
 	    label: Record<string, string>;
@@ -94,7 +196,11 @@ export namespace backend {
 	}
 	export class ModelCapabilities {
 	    supportsReferenceImage: boolean;
+	    requiresReferenceImage?: boolean;
 	    maxReferenceImages: number;
+	    maxImageBytes?: number;
+	    maxImagePixels?: number;
+	    acceptedMimeTypes?: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new ModelCapabilities(source);
@@ -103,7 +209,11 @@ export namespace backend {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.supportsReferenceImage = source["supportsReferenceImage"];
+	        this.requiresReferenceImage = source["requiresReferenceImage"];
 	        this.maxReferenceImages = source["maxReferenceImages"];
+	        this.maxImageBytes = source["maxImageBytes"];
+	        this.maxImagePixels = source["maxImagePixels"];
+	        this.acceptedMimeTypes = source["acceptedMimeTypes"];
 	    }
 	}
 	export class ProviderConfig {
@@ -188,60 +298,64 @@ export namespace backend {
 		    return a;
 		}
 	}
-	export class GenerateRequest {
-	    prompt: string;
-	    provider: string;
-	    model: string;
-	    size: string;
-	    images: string[];
-	    parameters: Record<string, any>;
+	export class DataRootInfo {
+	    path: string;
+	    imagesDir: string;
+	    portable: boolean;
+	    custom: boolean;
+	    importedFrom?: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new GenerateRequest(source);
+	        return new DataRootInfo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.prompt = source["prompt"];
-	        this.provider = source["provider"];
-	        this.model = source["model"];
-	        this.size = source["size"];
-	        this.images = source["images"];
-	        this.parameters = source["parameters"];
+	        this.path = source["path"];
+	        this.imagesDir = source["imagesDir"];
+	        this.portable = source["portable"];
+	        this.custom = source["custom"];
+	        this.importedFrom = source["importedFrom"];
 	    }
 	}
-	export class GeneratedImage {
-	    id: string;
-	    url: string;
-	    width?: number;
-	    height?: number;
+	export class PerceptualHash {
+	    aHash: string;
+	    dHash: string;
+	    pHash: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new GeneratedImage(source);
+	        return new PerceptualHash(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
-	        this.url = source["url"];
-	        this.width = source["width"];
-	        this.height = source["height"];
+	        this.aHash = source["aHash"];
+	        this.dHash = source["dHash"];
+	        this.pHash = source["pHash"];
 	    }
 	}
-	export class GenerateResponse {
-	    success: boolean;
-	    images?: GeneratedImage[];
-	    error?: APIError;
+	export class SimilarImage {
+	    ref: string;
+	    imageId?: string;
+	    recordId?: string;
+	    templateId?: string;
+	    timestamp?: number;
+	    distance: number;
+	    hash?: PerceptualHash;
 	
 	    static createFrom(source: any = {}) {
-	        return new GenerateResponse(source);
+	        return new SimilarImage(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.success = source["success"];
-	        this.images = this.convertValues(source["images"], GeneratedImage);
-	        this.error = this.convertValues(source["error"], APIError);
+	        this.ref = source["ref"];
+	        this.imageId = source["imageId"];
+	        this.recordId = source["recordId"];
+	        this.templateId = source["templateId"];
+	        this.timestamp = source["timestamp"];
+	        this.distance = source["distance"];
+	        this.hash = this.convertValues(source["hash"], PerceptualHash);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -262,45 +376,126 @@ export namespace backend {
 		    return a;
 		}
 	}
+	export class DuplicateGroup {
+	    images: SimilarImage[];
 	
-	export class GenerationParams {
-	    prompt: string;
-	    provider: string;
-	    model: string;
-	    size: string;
-	    parameters?: Record<string, any>;
+	    static createFrom(source: any = {}) {
+	        return new DuplicateGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.images = this.convertValues(source["images"], SimilarImage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DuplicateReport {
+	    threshold: number;
+	    scanned: number;
+	    duplicates: number;
+	    groups: DuplicateGroup[];
 	
 	    static createFrom(source: any = {}) {
-	        return new GenerationParams(source);
+	        return new DuplicateReport(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.prompt = source["prompt"];
-	        this.provider = source["provider"];
-	        this.model = source["model"];
+	        this.threshold = source["threshold"];
+	        this.scanned = source["scanned"];
+	        this.duplicates = source["duplicates"];
+	        this.groups = this.convertValues(source["groups"], DuplicateGroup);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExportIssue {
+	    recordId: string;
+	    path: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportIssue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recordId = source["recordId"];
+	        this.path = source["path"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class OrphanImage {
+	    path: string;
+	    size: number;
+	    legacy?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new OrphanImage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
 	        this.size = source["size"];
-	        this.parameters = source["parameters"];
+	        this.legacy = source["legacy"];
 	    }
 	}
-	export class HistoryRecord {
-	    id: string;
-	    params: GenerationParams;
-	    images: GeneratedImage[];
-	    timestamp: number;
-	    metadata?: Record<string, any>;
+	export class GarbageReport {
+	    dryRun: boolean;
+	    scanned: number;
+	    referenced: number;
+	    deleted: number;
+	    reclaimedBytes: number;
+	    orphans: OrphanImage[];
+	    errors?: string[];
 	
 	    static createFrom(source: any = {}) {
-	        return new HistoryRecord(source);
+	        return new GarbageReport(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
-	        this.params = this.convertValues(source["params"], GenerationParams);
-	        this.images = this.convertValues(source["images"], GeneratedImage);
-	        this.timestamp = source["timestamp"];
-	        this.metadata = source["metadata"];
+	        this.dryRun = source["dryRun"];
+	        this.scanned = source["scanned"];
+	        this.referenced = source["referenced"];
+	        this.deleted = source["deleted"];
+	        this.reclaimedBytes = source["reclaimedBytes"];
+	        this.orphans = this.convertValues(source["orphans"], OrphanImage);
+	        this.errors = source["errors"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -321,26 +516,56 @@ export namespace backend {
 		    return a;
 		}
 	}
+	export class PostProcessStep {
+	    type: string;
+	    width?: number;
+	    height?: number;
+	    scale?: number;
+	    aspect?: string;
+	    color?: string;
+	    text?: string;
+	    image?: string;
+	    position?: string;
+	    opacity?: number;
+	    size?: number;
+	    format?: string;
+	    quality?: number;
 	
+	    static createFrom(source: any = {}) {
+	        return new PostProcessStep(source);
+	    }
 	
-	export class ProviderInfo {
-	    id: string;
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.scale = source["scale"];
+	        this.aspect = source["aspect"];
+	        this.color = source["color"];
+	        this.text = source["text"];
+	        this.image = source["image"];
+	        this.position = source["position"];
+	        this.opacity = source["opacity"];
+	        this.size = source["size"];
+	        this.format = source["format"];
+	        this.quality = source["quality"];
+	    }
+	}
+	export class PostProcessPipeline {
 	    name: string;
-	    models: string[];
-	    sizeOptions: Record<string, Array<string>>;
-	    modelCapabilities: Record<string, ModelCapabilities>;
+	    description?: string;
+	    steps: PostProcessStep[];
 	
 	    static createFrom(source: any = {}) {
-	        return new ProviderInfo(source);
+	        return new PostProcessPipeline(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.id = source["id"];
 	        this.name = source["name"];
-	        this.models = source["models"];
-	        this.sizeOptions = source["sizeOptions"];
-	        this.modelCapabilities = this.convertValues(source["modelCapabilities"], ModelCapabilities, true);
+	        this.description = source["description"];
+	        this.steps = this.convertValues(source["steps"], PostProcessStep);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -361,16 +586,42 @@ export namespace backend {
 		    return a;
 		}
 	}
-	export class ProvidersResponse {
-	    providers: ProviderInfo[];
+	export class GenerateRequest {
+	    prompt: string;
+	    provider: string;
+	    model: string;
+	    size: string;
+	    images: string[];
+	    parameters: Record<string, any>;
+	    negativePrompt?: string;
+	    seed?: number;
+	    templateId?: string;
+	    templateRevision?: number;
+	    language?: string;
+	    selections?: Record<string, string>;
+	    pipeline?: string;
+	    postProcess?: PostProcessPipeline;
 	
 	    static createFrom(source: any = {}) {
-	        return new ProvidersResponse(source);
+	        return new GenerateRequest(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.providers = this.convertValues(source["providers"], ProviderInfo);
+	        this.prompt = source["prompt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.size = source["size"];
+	        this.images = source["images"];
+	        this.parameters = source["parameters"];
+	        this.negativePrompt = source["negativePrompt"];
+	        this.seed = source["seed"];
+	        this.templateId = source["templateId"];
+	        this.templateRevision = source["templateRevision"];
+	        this.language = source["language"];
+	        this.selections = source["selections"];
+	        this.pipeline = source["pipeline"];
+	        this.postProcess = this.convertValues(source["postProcess"], PostProcessPipeline);
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -391,28 +642,1214 @@ export namespace backend {
 		    return a;
 		}
 	}
+	export class GenerationUsage {
+	    images: number;
+	    inputTokens?: number;
+	    outputTokens?: number;
+	    cost: number;
+	    currency: string;
+	    requestId?: string;
 	
-	export class Template {
+	    static createFrom(source: any = {}) {
+	        return new GenerationUsage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.images = source["images"];
+	        this.inputTokens = source["inputTokens"];
+	        this.outputTokens = source["outputTokens"];
+	        this.cost = source["cost"];
+	        this.currency = source["currency"];
+	        this.requestId = source["requestId"];
+	    }
+	}
+	export class GeneratedImage {
 	    id: string;
-	    name: Record<string, string>;
-	    content: Record<string, string>;
-	    imageUrl: string;
-	    imageUrls?: string[];
-	    author: string;
+	    url: string;
+	    width?: number;
+	    height?: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new Template(source);
+	        return new GeneratedImage(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.id = source["id"];
-	        this.name = source["name"];
-	        this.content = source["content"];
-	        this.imageUrl = source["imageUrl"];
-	        this.imageUrls = source["imageUrls"];
-	        this.author = source["author"];
+	        this.url = source["url"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	}
+	export class GenerateResponse {
+	    success: boolean;
+	    images?: GeneratedImage[];
+	    error?: APIError;
+	    seed?: number;
+	    usage?: GenerationUsage;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateResponse(source);
 	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.images = this.convertValues(source["images"], GeneratedImage);
+	        this.error = this.convertValues(source["error"], APIError);
+	        this.seed = source["seed"];
+	        this.usage = this.convertValues(source["usage"], GenerationUsage);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class GenerationParams {
+	    prompt: string;
+	    provider: string;
+	    model: string;
+	    size: string;
+	    parameters?: Record<string, any>;
+	    templateId?: string;
+	    templateRevision?: number;
+	    language?: string;
+	    selections?: Record<string, string>;
+	    seed?: number;
+	    negativePrompt?: string;
+	    referenceImages?: string[];
+	    regeneratedFrom?: string;
+	    postProcess?: PostProcessPipeline;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerationParams(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.prompt = source["prompt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.size = source["size"];
+	        this.parameters = source["parameters"];
+	        this.templateId = source["templateId"];
+	        this.templateRevision = source["templateRevision"];
+	        this.language = source["language"];
+	        this.selections = source["selections"];
+	        this.seed = source["seed"];
+	        this.negativePrompt = source["negativePrompt"];
+	        this.referenceImages = source["referenceImages"];
+	        this.regeneratedFrom = source["regeneratedFrom"];
+	        this.postProcess = this.convertValues(source["postProcess"], PostProcessPipeline);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class GridOptions {
+	    columns?: number;
+	    tileSize?: number;
+	    padding?: number;
+	    background?: string;
+	    caption?: string;
+	    captions?: string[];
+	    captionColor?: string;
+	    title?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GridOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.columns = source["columns"];
+	        this.tileSize = source["tileSize"];
+	        this.padding = source["padding"];
+	        this.background = source["background"];
+	        this.caption = source["caption"];
+	        this.captions = source["captions"];
+	        this.captionColor = source["captionColor"];
+	        this.title = source["title"];
+	    }
+	}
+	export class ModelStats {
+	    provider: string;
+	    model: string;
+	    attempts: number;
+	    successes: number;
+	    failures: number;
+	    rejected: number;
+	    successRate: number;
+	    avgLatencyMs: number;
+	    errorCodes?: Record<string, number>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ModelStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.attempts = source["attempts"];
+	        this.successes = source["successes"];
+	        this.failures = source["failures"];
+	        this.rejected = source["rejected"];
+	        this.successRate = source["successRate"];
+	        this.avgLatencyMs = source["avgLatencyMs"];
+	        this.errorCodes = source["errorCodes"];
+	    }
+	}
+	export class HistoryAnalytics {
+	    generations: number;
+	    images: number;
+	    perDay: AnalyticsBucket[];
+	    perWeek: AnalyticsBucket[];
+	    perProvider: AnalyticsBucket[];
+	    models: ModelStats[];
+	    topTemplates: AnalyticsBucket[];
+	    topBankOptions: BankOptionCount[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryAnalytics(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.generations = source["generations"];
+	        this.images = source["images"];
+	        this.perDay = this.convertValues(source["perDay"], AnalyticsBucket);
+	        this.perWeek = this.convertValues(source["perWeek"], AnalyticsBucket);
+	        this.perProvider = this.convertValues(source["perProvider"], AnalyticsBucket);
+	        this.models = this.convertValues(source["models"], ModelStats);
+	        this.topTemplates = this.convertValues(source["topTemplates"], AnalyticsBucket);
+	        this.topBankOptions = this.convertValues(source["topBankOptions"], BankOptionCount);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryDeleteReport {
+	    dryRun: boolean;
+	    recordIds: string[];
+	    deleted: number;
+	    reclaimedBytes: number;
+	    errors?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryDeleteReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.dryRun = source["dryRun"];
+	        this.recordIds = source["recordIds"];
+	        this.deleted = source["deleted"];
+	        this.reclaimedBytes = source["reclaimedBytes"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class HistoryExportOptions {
+	    recordIds: string[];
+	    format: string;
+	    destination: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recordIds = source["recordIds"];
+	        this.format = source["format"];
+	        this.destination = source["destination"];
+	    }
+	}
+	export class HistoryExportResult {
+	    path: string;
+	    records: number;
+	    images: number;
+	    missing: ExportIssue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.records = source["records"];
+	        this.images = source["images"];
+	        this.missing = this.convertValues(source["missing"], ExportIssue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryRecord {
+	    id: string;
+	    params: GenerationParams;
+	    images: GeneratedImage[];
+	    timestamp: number;
+	    metadata?: Record<string, any>;
+	    sourceRecords?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.params = this.convertValues(source["params"], GenerationParams);
+	        this.images = this.convertValues(source["images"], GeneratedImage);
+	        this.timestamp = source["timestamp"];
+	        this.metadata = source["metadata"];
+	        this.sourceRecords = source["sourceRecords"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryPage {
+	    records: HistoryRecord[];
+	    total: number;
+	    totalAll: number;
+	    nextCursor: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.records = this.convertValues(source["records"], HistoryRecord);
+	        this.total = source["total"];
+	        this.totalAll = source["totalAll"];
+	        this.nextCursor = source["nextCursor"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HistoryQuery {
+	    provider: string;
+	    model: string;
+	    size: string;
+	    templateId: string;
+	    prompt: string;
+	    from: number;
+	    to: number;
+	    hasImages?: boolean;
+	    starred?: boolean;
+	    minRating: number;
+	    labels: string[];
+	    sort: string;
+	    cursor: string;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.size = source["size"];
+	        this.templateId = source["templateId"];
+	        this.prompt = source["prompt"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.hasImages = source["hasImages"];
+	        this.starred = source["starred"];
+	        this.minRating = source["minRating"];
+	        this.labels = source["labels"];
+	        this.sort = source["sort"];
+	        this.cursor = source["cursor"];
+	        this.limit = source["limit"];
+	    }
+	}
+	
+	export class ImageEncoding {
+	    sourceFormat: string;
+	    passThrough: boolean;
+	    resized?: boolean;
+	    quality?: number;
+	    metadata?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageEncoding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sourceFormat = source["sourceFormat"];
+	        this.passThrough = source["passThrough"];
+	        this.resized = source["resized"];
+	        this.quality = source["quality"];
+	        this.metadata = source["metadata"];
+	    }
+	}
+	export class ImageExportOptions {
+	    recordIds: string[];
+	    imageIds: string[];
+	    templateIds: string[];
+	    destination: string;
+	    pattern: string;
+	    sidecar: string;
+	    overwrite: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageExportOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recordIds = source["recordIds"];
+	        this.imageIds = source["imageIds"];
+	        this.templateIds = source["templateIds"];
+	        this.destination = source["destination"];
+	        this.pattern = source["pattern"];
+	        this.sidecar = source["sidecar"];
+	        this.overwrite = source["overwrite"];
+	    }
+	}
+	export class ImageExportResult {
+	    path: string;
+	    exported: number;
+	    files: string[];
+	    missing: ExportIssue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.exported = source["exported"];
+	        this.files = source["files"];
+	        this.missing = this.convertValues(source["missing"], ExportIssue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImageMetadata {
+	    prompt: string;
+	    negativePrompt?: string;
+	    provider?: string;
+	    model?: string;
+	    size?: string;
+	    seed?: number;
+	    templateId?: string;
+	    parameters?: string;
+	    chunks?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageMetadata(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.prompt = source["prompt"];
+	        this.negativePrompt = source["negativePrompt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.size = source["size"];
+	        this.seed = source["seed"];
+	        this.templateId = source["templateId"];
+	        this.parameters = source["parameters"];
+	        this.chunks = source["chunks"];
+	    }
+	}
+	export class ImageMigrationReport {
+	    moved: number;
+	    rewrittenPaths: number;
+	    missing?: string[];
+	    errors?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageMigrationReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.moved = source["moved"];
+	        this.rewrittenPaths = source["rewrittenPaths"];
+	        this.missing = source["missing"];
+	        this.errors = source["errors"];
+	    }
+	}
+	export class IngestRequest {
+	    recordId?: string;
+	    images: GeneratedImage[];
+	    params: GenerationParams;
+	    timestamp?: number;
+	    metadata?: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new IngestRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.recordId = source["recordId"];
+	        this.images = this.convertValues(source["images"], GeneratedImage);
+	        this.params = this.convertValues(source["params"], GenerationParams);
+	        this.timestamp = source["timestamp"];
+	        this.metadata = source["metadata"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class IngestedImage {
+	    source: string;
+	    ref?: string;
+	    format?: string;
+	    width?: number;
+	    height?: number;
+	    size?: number;
+	    error?: string;
+	    warning?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new IngestedImage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.ref = source["ref"];
+	        this.format = source["format"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.size = source["size"];
+	        this.error = source["error"];
+	        this.warning = source["warning"];
+	    }
+	}
+	export class IngestResult {
+	    record?: HistoryRecord;
+	    images: IngestedImage[];
+	    failed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new IngestResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.record = this.convertValues(source["record"], HistoryRecord);
+	        this.images = this.convertValues(source["images"], IngestedImage);
+	        this.failed = source["failed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class ModelPrice {
+	    perImage: number;
+	    perMillionInputTokens?: number;
+	    perMillionOutputTokens?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ModelPrice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.perImage = source["perImage"];
+	        this.perMillionInputTokens = source["perMillionInputTokens"];
+	        this.perMillionOutputTokens = source["perMillionOutputTokens"];
+	    }
+	}
+	
+	
+	
+	
+	
+	
+	export class ProviderInfo {
+	    id: string;
+	    name: string;
+	    models: string[];
+	    sizeOptions: Record<string, Array<string>>;
+	    modelCapabilities: Record<string, ModelCapabilities>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.models = source["models"];
+	        this.sizeOptions = source["sizeOptions"];
+	        this.modelCapabilities = this.convertValues(source["modelCapabilities"], ModelCapabilities, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProvidersResponse {
+	    providers: ProviderInfo[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProvidersResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.providers = this.convertValues(source["providers"], ProviderInfo);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ReferenceImageInfo {
+	    source: string;
+	    mimeType?: string;
+	    size?: number;
+	    width?: number;
+	    height?: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReferenceImageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.mimeType = source["mimeType"];
+	        this.size = source["size"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.error = source["error"];
+	    }
+	}
+	export class RegenerateOverrides {
+	    prompt: string;
+	    provider: string;
+	    model: string;
+	    size: string;
+	    negativePrompt?: string;
+	    seed?: number;
+	    randomSeed: boolean;
+	    parameters: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new RegenerateOverrides(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.prompt = source["prompt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.size = source["size"];
+	        this.negativePrompt = source["negativePrompt"];
+	        this.seed = source["seed"];
+	        this.randomSeed = source["randomSeed"];
+	        this.parameters = source["parameters"];
+	    }
+	}
+	export class RegenerateResult {
+	    response?: GenerateResponse;
+	    record?: HistoryRecord;
+	
+	    static createFrom(source: any = {}) {
+	        return new RegenerateResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.response = this.convertValues(source["response"], GenerateResponse);
+	        this.record = this.convertValues(source["record"], HistoryRecord);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class RetentionPolicy {
+	    keepLast: number;
+	    maxAgeDays: number;
+	    maxTotalSizeGB: number;
+	    runAtStartup: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetentionPolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keepLast = source["keepLast"];
+	        this.maxAgeDays = source["maxAgeDays"];
+	        this.maxTotalSizeGB = source["maxTotalSizeGB"];
+	        this.runAtStartup = source["runAtStartup"];
+	    }
+	}
+	export class SearchHighlight {
+	    field: string;
+	    snippet: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchHighlight(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.snippet = source["snippet"];
+	    }
+	}
+	export class SearchOptions {
+	    kinds: string[];
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kinds = source["kinds"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class SearchResult {
+	    kind: string;
+	    id: string;
+	    title: string;
+	    score: number;
+	    highlights: SearchHighlight[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.id = source["id"];
+	        this.title = source["title"];
+	        this.score = source["score"];
+	        this.highlights = this.convertValues(source["highlights"], SearchHighlight);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SearchResponse {
+	    results: SearchResult[];
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SearchResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.results = this.convertValues(source["results"], SearchResult);
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class StorageSettings {
+	    format: string;
+	    jpegQuality: number;
+	    maxDimension: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new StorageSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.jpegQuality = source["jpegQuality"];
+	        this.maxDimension = source["maxDimension"];
+	    }
+	}
+	export class StoredImage {
+	    id: string;
+	    path: string;
+	    format: string;
+	    width: number;
+	    height: number;
+	    size: number;
+	    createdAt: number;
+	    refs: string[];
+	    encoding?: ImageEncoding;
+	    hash?: PerceptualHash;
+	    hashFailed?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new StoredImage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.path = source["path"];
+	        this.format = source["format"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.size = source["size"];
+	        this.createdAt = source["createdAt"];
+	        this.refs = source["refs"];
+	        this.encoding = this.convertValues(source["encoding"], ImageEncoding);
+	        this.hash = this.convertValues(source["hash"], PerceptualHash);
+	        this.hashFailed = source["hashFailed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TagCount {
+	    tag: string;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagCount(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tag = source["tag"];
+	        this.count = source["count"];
+	    }
+	}
+	export class Template {
+	    id: string;
+	    name: Record<string, string>;
+	    content: Record<string, string>;
+	    imageUrl: string;
+	    imageUrls?: string[];
+	    author: string;
+	    tags?: string[];
+	    revision?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Template(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.content = source["content"];
+	        this.imageUrl = source["imageUrl"];
+	        this.imageUrls = source["imageUrls"];
+	        this.author = source["author"];
+	        this.tags = source["tags"];
+	        this.revision = source["revision"];
+	    }
+	}
+	export class TemplateQuery {
+	    tags: string[];
+	    tagMode: string;
+	    author: string;
+	    languages: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TemplateQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tags = source["tags"];
+	        this.tagMode = source["tagMode"];
+	        this.author = source["author"];
+	        this.languages = source["languages"];
+	    }
+	}
+	
+	export class UsageEntry {
+	    timestamp: number;
+	    user: string;
+	    provider: string;
+	    model: string;
+	    images: number;
+	    inputTokens?: number;
+	    outputTokens?: number;
+	    cost: number;
+	    currency: string;
+	    requestId?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.timestamp = source["timestamp"];
+	        this.user = source["user"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.images = source["images"];
+	        this.inputTokens = source["inputTokens"];
+	        this.outputTokens = source["outputTokens"];
+	        this.cost = source["cost"];
+	        this.currency = source["currency"];
+	        this.requestId = source["requestId"];
+	    }
+	}
+	export class UsageGroup {
+	    key: string;
+	    requests: number;
+	    images: number;
+	    inputTokens: number;
+	    outputTokens: number;
+	    cost: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.requests = source["requests"];
+	        this.images = source["images"];
+	        this.inputTokens = source["inputTokens"];
+	        this.outputTokens = source["outputTokens"];
+	        this.cost = source["cost"];
+	    }
+	}
+	export class UsageQuery {
+	    from?: number;
+	    to?: number;
+	    provider?: string;
+	    user?: string;
+	    groupBy?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.provider = source["provider"];
+	        this.user = source["user"];
+	        this.groupBy = source["groupBy"];
+	    }
+	}
+	export class UsageSettings {
+	    currency: string;
+	    prices: Record<string, any>;
+	    budgets: UsageBudget[];
+	    user: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.currency = source["currency"];
+	        this.prices = source["prices"];
+	        this.budgets = this.convertValues(source["budgets"], UsageBudget);
+	        this.user = source["user"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class UsageSummary {
+	    currency: string;
+	    requests: number;
+	    images: number;
+	    cost: number;
+	    groups: UsageGroup[];
+	    budgets: BudgetStatus[];
+	
+	    static createFrom(source: any = {}) {
+	        return new UsageSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.currency = source["currency"];
+	        this.requests = source["requests"];
+	        this.images = source["images"];
+	        this.cost = source["cost"];
+	        this.groups = this.convertValues(source["groups"], UsageGroup);
+	        this.budgets = this.convertValues(source["budgets"], BudgetStatus);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
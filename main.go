@@ -32,7 +32,8 @@ func main() {
 		Frameless:        true,              // Frameless window
 		DisableResize:    false,             // Allow resize
 		AssetServer: &assetserver.Options{
-			Assets: assets,
+			Assets:  assets,
+			Handler: backend.NewLocalImageHandler(app), // Serves /localimg/ from the data root
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 0}, // Transparent background to let CSS handle it
 		Bind:             []interface{}{app},                       // Bind App methods to frontend